@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/jjleng/cp-runner/pkg/api"
+	"github.com/jjleng/cp-runner/pkg/listenfd"
 	"github.com/jjleng/cp-runner/pkg/project"
 	"github.com/jjleng/cp-runner/pkg/proxy"
 	"github.com/jjleng/cp-runner/pkg/runtime"
@@ -37,6 +42,39 @@ func getEnvFromEnvVar() Environment {
 	return Development
 }
 
+// waitForShutdown blocks until SIGINT or SIGTERM, then runs cleanup and
+// returns once it finishes. If cleanup hasn't finished within timeout, or a
+// second signal arrives first, forceKill is called to give the in-flight
+// dev server process no choice but to exit, and the process exits
+// immediately with status 1 rather than waiting any longer.
+func waitForShutdown(timeout time.Duration, cleanup func(), forceKill func()) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+	log.Println("Shutting down, press Ctrl-C again to force quit")
+
+	second := make(chan os.Signal, 1)
+	signal.Notify(second, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		cleanup()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-second:
+		log.Println("Received second signal, forcing shutdown")
+	case <-time.After(timeout):
+		log.Println("Shutdown timed out, forcing exit")
+	}
+
+	forceKill()
+	os.Exit(1)
+}
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "CodePanda",
@@ -45,8 +83,10 @@ func main() {
 
 	// preview command
 	var (
-		packageManager string
-		port           int
+		packageManager  string
+		port            int
+		liveReload      bool
+		shutdownTimeout time.Duration
 	)
 
 	previewCmd := &cobra.Command{
@@ -74,27 +114,32 @@ func main() {
 
 			// Initialize project orchestrator
 			orchestrator := project.NewOrchestrator(projectPath, pm)
+			orchestrator.SetLiveReload(liveReload)
 
 			// Run the project
-			if err := orchestrator.RestartProject(port, appPort); err != nil {
+			if err := orchestrator.RestartProject(context.Background(), port, appPort); err != nil {
 				log.Fatalf("Failed to start project: %v", err)
 			}
 
 			fmt.Printf("Development server started on port %d\n", port)
 
-			// Keep the process running
-			select {}
+			// Block until SIGINT/SIGTERM, then stop the dev server and proxy
+			// before exiting so we don't leave an orphaned pnpm dev child.
+			waitForShutdown(shutdownTimeout, orchestrator.Cleanup, orchestrator.ForceKill)
 		},
 	}
 
 	previewCmd.Flags().StringVar(&packageManager, "package-manager", "pnpm", "Package manager to use (npm|pnpm|yarn)")
 	previewCmd.Flags().IntVar(&port, "port", 3000, "Port to run the preview server on")
+	previewCmd.Flags().BoolVar(&liveReload, "live-reload", false, "Inject a live-reload client and push reload events when workspace files change")
+	previewCmd.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", 10*time.Second, "How long to wait for a graceful shutdown before forcing the dev server to exit")
 
 	// control command
 	var (
-		host        string
-		controlPort int
-		proxyPort   int
+		host                   string
+		controlPort            int
+		proxyPort              int
+		controlShutdownTimeout time.Duration
 	)
 
 	controlCmd := &cobra.Command{
@@ -134,12 +179,43 @@ func main() {
 			// Initialize the control plane server
 			controlServer := api.NewControlPlaneServer(controlPort, proxyPort, pm, workspacePath)
 
-			// Start the control plane server
+			httpServer := &http.Server{
+				Addr:    fmt.Sprintf("%s:%d", host, controlPort),
+				Handler: controlServer.Routes(),
+			}
+
+			// Start the control plane server, picking up a socket-activated
+			// listener if one was passed to us under the name "control" so a
+			// binary restart doesn't drop in-flight requests.
 			log.Printf("Starting control plane server on %s:%d", host, controlPort)
 			log.Printf("Using workspace path: %s", workspacePath)
-			if err := http.ListenAndServe(fmt.Sprintf("%s:%d", host, controlPort), controlServer.Routes()); err != nil {
-				log.Fatalf("Control plane server error: %v", err)
+			l, ok, err := listenfd.Take("control")
+			if err != nil {
+				log.Fatalf("listenfd: %v", err)
 			}
+			go func() {
+				var serveErr error
+				if ok {
+					log.Println("Control plane server using socket-activated listener")
+					serveErr = httpServer.Serve(l)
+				} else {
+					serveErr = httpServer.ListenAndServe()
+				}
+				if serveErr != nil && serveErr != http.ErrServerClosed {
+					log.Fatalf("Control plane server error: %v", serveErr)
+				}
+			}()
+
+			// Block until SIGINT/SIGTERM, then drain the HTTP server and stop
+			// every tracked project's dev server before exiting.
+			waitForShutdown(controlShutdownTimeout, func() {
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), controlShutdownTimeout)
+				defer cancel()
+				if err := httpServer.Shutdown(shutdownCtx); err != nil {
+					log.Printf("Control plane server shutdown error: %v", err)
+				}
+				controlServer.Cleanup()
+			}, controlServer.ForceKillAll)
 		},
 	}
 
@@ -147,6 +223,7 @@ func main() {
 	controlCmd.Flags().IntVar(&controlPort, "port", 8088, "Port to run the control plane on")
 	controlCmd.Flags().IntVar(&proxyPort, "proxy-port", 3000, "Port to run the proxy server on")
 	controlCmd.Flags().StringVar(&packageManager, "package-manager", "pnpm", "Package manager to use (npm|pnpm|yarn)")
+	controlCmd.Flags().DurationVar(&controlShutdownTimeout, "shutdown-timeout", 10*time.Second, "How long to wait for a graceful shutdown before forcing dev servers to exit")
 
 	rootCmd.AddCommand(previewCmd, controlCmd)
 
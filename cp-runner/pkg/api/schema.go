@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 // FileSystemResponse represents a response containing file system structure
 type FileSystemResponse struct {
 	Body struct {
@@ -11,9 +13,18 @@ type FileSystemResponse struct {
 type FileNode struct {
 	Name     string     `json:"name" doc:"Name of the file or directory"`
 	Type     string     `json:"type" doc:"Type of the node (file or folder)"`
+	Ignored  bool       `json:"ignored,omitempty" doc:"Whether this entry matched a .gitignore/.cpignore rule"`
 	Children []FileNode `json:"children,omitempty" doc:"Child nodes for directories"`
 }
 
+// HookResult is the outcome of running a single project hook script
+// (from .git/hooks or .codepanda/hooks) after a mutating git operation.
+type HookResult struct {
+	Name     string `json:"name" doc:"Hook script path relative to the project root"`
+	ExitCode int    `json:"exit_code" doc:"Exit code of the hook script"`
+	Output   string `json:"output" doc:"Combined stdout/stderr of the hook script"`
+}
+
 // ProjectOperationRequest represents a request to perform an operation on a project
 type ProjectOperationRequest struct {
 	Body struct {
@@ -45,9 +56,35 @@ type BuildErrorResponse struct {
 }
 
 type GetFileTreeRequest struct {
+	ProjectID     string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+	IncludeHidden bool   `json:"include_hidden" query:"include_hidden" doc:"Include dotfiles and dotdirs in the tree"`
+}
+
+// StreamEvent is a single SSE message emitted while streaming a lint,
+// build, or add-package run.
+type StreamEvent struct {
+	Type string `json:"type" doc:"stdout, stderr, or exit"`
+	Data string `json:"data,omitempty" doc:"Output line, for stdout/stderr events"`
+	Code int    `json:"code,omitempty" doc:"Process exit code, set only on the exit event"`
+}
+
+// RunLintStreamRequest represents a request to stream a lint run's output
+type RunLintStreamRequest struct {
 	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
 }
 
+// CheckBuildStreamRequest represents a request to stream a build/type check run's output
+type CheckBuildStreamRequest struct {
+	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+}
+
+// AddPackageStreamRequest represents a request to stream a package install's output
+type AddPackageStreamRequest struct {
+	ProjectID   string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+	PackageName string `json:"package_name" query:"package_name" required:"true" doc:"Name of the package to add"`
+	Dev         bool   `json:"dev" query:"dev" doc:"Whether to add the package as a dev dependency (default: false)"`
+}
+
 // GetFileContentRequest represents a request to get file content
 type GetFileContentRequest struct {
 	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
@@ -71,8 +108,9 @@ type GetCommitsRequest struct {
 
 // CommitFile represents a file in a commit
 type CommitFile struct {
-	Path string `json:"path" doc:"Path of the changed file"`
-	Type string `json:"type" doc:"Type of change (added, modified, deleted)"`
+	Path    string `json:"path" doc:"Path of the changed file"`
+	OldPath string `json:"old_path,omitempty" doc:"Previous path, set only when type is renamed"`
+	Type    string `json:"type" doc:"Type of change (added, modified, deleted, renamed)"`
 }
 
 // Commit represents a git commit
@@ -114,9 +152,10 @@ type GetFileDiffRequest struct {
 
 // FileDiff represents changes in a file
 type FileDiff struct {
-	Path    string `json:"path" doc:"Path of the file"`
-	OldText string `json:"old_text" doc:"Original content of the file"`
-	NewText string `json:"new_text" doc:"Modified content of the file"`
+	Path     string `json:"path" doc:"Path of the file"`
+	OldText  string `json:"old_text" doc:"Original content of the file"`
+	NewText  string `json:"new_text" doc:"Modified content of the file"`
+	IsBinary bool   `json:"is_binary" doc:"Whether either side of the file is binary; a binary side's text is empty, a text side's is still set"`
 }
 
 // GetFileDiffResponse represents a response containing file diff
@@ -129,23 +168,173 @@ type GetFileDiffResponse struct {
 // SwitchCommitRequest represents a request to switch to a specific commit
 type SwitchCommitRequest struct {
 	Body struct {
-		ProjectID  string `json:"project_id" required:"true" doc:"ID of the project"`
-		CommitHash string `json:"commit_hash" required:"true" doc:"Hash of the commit to switch to"`
+		ProjectID    string `json:"project_id" required:"true" doc:"ID of the project"`
+		CommitHash   string `json:"commit_hash" required:"true" doc:"Hash of the commit to switch to"`
+		Mode         string `json:"mode" doc:"How to move to the commit: hard, mixed, soft, or checkout (default: hard)"`
+		CreateBranch string `json:"create_branch,omitempty" doc:"If set, create a new branch with this name pointing at commit_hash and switch to it, instead of moving the current branch"`
+		Force        bool   `json:"force" doc:"Required to hard-reset or checkout over a dirty working tree"`
 	}
 }
 
 // SwitchCommitResponse represents a response from a commit switch operation
 type SwitchCommitResponse struct {
+	Body struct {
+		Message string       `json:"message" doc:"Operation result message"`
+		Hooks   []HookResult `json:"hooks" doc:"Hook scripts run as a result of this operation"`
+	}
+}
+
+// Branch represents a local git branch
+type Branch struct {
+	Name      string `json:"name" doc:"Branch name"`
+	IsCurrent bool   `json:"is_current" doc:"Whether this is the currently checked-out branch"`
+}
+
+// ListBranchesRequest represents a request to list a project's branches
+type ListBranchesRequest struct {
+	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+}
+
+// ListBranchesResponse represents a response containing a project's branches
+type ListBranchesResponse struct {
+	Body struct {
+		Branches []Branch `json:"branches" doc:"Local branches"`
+	}
+}
+
+// CreateBranchRequest represents a request to create a new branch
+type CreateBranchRequest struct {
+	Body struct {
+		ProjectID   string `json:"project_id" required:"true" doc:"ID of the project"`
+		Name        string `json:"name" required:"true" doc:"Name of the branch to create"`
+		StartCommit string `json:"start_commit,omitempty" doc:"Commit the branch should point at (default: HEAD)"`
+	}
+}
+
+// CreateBranchResponse represents a response from creating a branch
+type CreateBranchResponse struct {
+	Body struct {
+		Message string `json:"message" doc:"Operation result message"`
+	}
+}
+
+// SwitchBranchRequest represents a request to switch to a branch
+type SwitchBranchRequest struct {
+	Body struct {
+		ProjectID string `json:"project_id" required:"true" doc:"ID of the project"`
+		Name      string `json:"name" required:"true" doc:"Name of the branch to switch to"`
+		Force     bool   `json:"force" doc:"Discard conflicting local changes to complete the checkout"`
+	}
+}
+
+// SwitchBranchResponse represents a response from switching branches
+type SwitchBranchResponse struct {
+	Body struct {
+		Message string `json:"message" doc:"Operation result message"`
+	}
+}
+
+// Remote represents a configured git remote
+type Remote struct {
+	Name string   `json:"name" doc:"Remote name"`
+	URLs []string `json:"urls" doc:"URLs configured for the remote"`
+}
+
+// ListRemotesRequest represents a request to list a project's remotes
+type ListRemotesRequest struct {
+	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+}
+
+// ListRemotesResponse represents a response containing a project's remotes
+type ListRemotesResponse struct {
+	Body struct {
+		Remotes []Remote `json:"remotes" doc:"Configured remotes"`
+	}
+}
+
+// AddRemoteRequest represents a request to register a new remote
+type AddRemoteRequest struct {
+	Body struct {
+		ProjectID string `json:"project_id" required:"true" doc:"ID of the project"`
+		Name      string `json:"name" required:"true" doc:"Name of the remote, e.g. origin"`
+		URL       string `json:"url" required:"true" doc:"URL of the remote repository"`
+	}
+}
+
+// AddRemoteResponse represents a response from registering a remote
+type AddRemoteResponse struct {
+	Body struct {
+		Message string `json:"message" doc:"Operation result message"`
+	}
+}
+
+// PushRequest represents a request to push a branch to a remote
+type PushRequest struct {
+	Authorization string `header:"Authorization" doc:"Forwarded as a credential source if no token is given; takes priority over netrc and cookiefile"`
+	Body          struct {
+		ProjectID string `json:"project_id" required:"true" doc:"ID of the project"`
+		Remote    string `json:"remote" doc:"Name of the remote to push to (default: origin)"`
+		Branch    string `json:"branch,omitempty" doc:"Branch to push (default: the remote's configured refspecs)"`
+		Force     bool   `json:"force" doc:"Force-push, allowing non-fast-forward updates"`
+		Token     string `json:"token,omitempty" doc:"Explicit auth token; takes priority over the Authorization header, netrc, and cookiefile"`
+	}
+}
+
+// PushResponse represents a response from a push operation
+type PushResponse struct {
 	Body struct {
 		Message string `json:"message" doc:"Operation result message"`
 	}
 }
 
+// PullRequest represents a request to pull a branch from a remote
+type PullRequest struct {
+	Authorization string `header:"Authorization" doc:"Forwarded as a credential source if no token is given; takes priority over netrc and cookiefile"`
+	Body          struct {
+		ProjectID string `json:"project_id" required:"true" doc:"ID of the project"`
+		Remote    string `json:"remote" doc:"Name of the remote to pull from (default: origin)"`
+		Branch    string `json:"branch,omitempty" doc:"Branch to pull (default: HEAD's upstream)"`
+		Force     bool   `json:"force" doc:"Allow updating the local branch even if it doesn't descend from the remote branch"`
+		Token     string `json:"token,omitempty" doc:"Explicit auth token; takes priority over the Authorization header, netrc, and cookiefile"`
+	}
+}
+
+// PullResponse represents a response from a pull operation
+type PullResponse struct {
+	Body struct {
+		Message string `json:"message" doc:"Operation result message"`
+	}
+}
+
+// CreateCommitRequest represents a request to stage changes and create a commit
+type CreateCommitRequest struct {
+	Body struct {
+		ProjectID   string   `json:"project_id" required:"true" doc:"ID of the project"`
+		Message     string   `json:"message" required:"true" doc:"Commit message"`
+		AuthorName  string   `json:"author_name" required:"true" doc:"Name of the commit author"`
+		AuthorEmail string   `json:"author_email" required:"true" doc:"Email of the commit author"`
+		Paths       []string `json:"paths,omitempty" doc:"Paths to stage, relative to the project root; all modified files are staged if omitted"`
+		AllowEmpty  bool     `json:"allow_empty" doc:"Allow creating a commit with no staged changes"`
+		DryRun      bool     `json:"dry_run" doc:"Compute the files that would be committed without writing a commit"`
+	}
+}
+
+// CreateCommitResponse represents a response from creating a commit
+type CreateCommitResponse struct {
+	Body struct {
+		Hash   string       `json:"hash,omitempty" doc:"Hash of the new commit, empty for a dry run"`
+		Files  []string     `json:"files" doc:"Paths included in the commit"`
+		DryRun bool         `json:"dry_run" doc:"Whether this was a dry run and no commit was written"`
+		Hooks  []HookResult `json:"hooks" doc:"Hook scripts run as a result of this operation, empty for a dry run"`
+	}
+}
+
 // AddPackageRequest represents a request to add a package
 type AddPackageRequest struct {
 	Body struct {
 		ProjectID     string `json:"project_id" required:"true" doc:"ID of the project"`
 		PackageName   string `json:"package_name" required:"true" doc:"Name of the package to add"`
+		Dev           bool   `json:"dev" doc:"Whether to add the package as a dev dependency (default: false)"`
 		RestartServer bool   `json:"restart_server" doc:"Whether to restart the server after installing the package (default: false)"`
 	}
 }
@@ -153,7 +342,58 @@ type AddPackageRequest struct {
 // AddPackageResponse represents a response from a package installation
 type AddPackageResponse struct {
 	Body struct {
-		Success bool   `json:"success" doc:"Whether the package installation was successful"`
-		Message string `json:"message" doc:"Installation output or error message"`
+		Success bool         `json:"success" doc:"Whether the package installation was successful"`
+		Message string       `json:"message" doc:"Installation output or error message"`
+		Hooks   []HookResult `json:"hooks" doc:"Hook scripts run as a result of this operation"`
+	}
+}
+
+// GetProjectStatusRequest represents a request for a project's dev server supervision state
+type GetProjectStatusRequest struct {
+	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+}
+
+// GetProjectStatusResponse represents a response describing a project's dev server supervision state
+type GetProjectStatusResponse struct {
+	Body struct {
+		State       string `json:"state" doc:"Dev server supervision state: stopped, starting, running, backoff, or fatal"`
+		LastFailure string `json:"last_failure,omitempty" doc:"Reason the dev server most recently went fatal, if it ever has"`
 	}
 }
+
+// GetDiagnosticsRequest represents a request for a project's latest build diagnostics
+type GetDiagnosticsRequest struct {
+	ProjectID string `json:"project_id" query:"project_id" required:"true" doc:"ID of the project"`
+}
+
+// Diagnostic represents a single structured error or warning parsed from dev-server output
+type Diagnostic struct {
+	File     string `json:"file" doc:"Path of the file the diagnostic applies to"`
+	Line     int    `json:"line" doc:"1-based line number"`
+	Col      int    `json:"col" doc:"1-based column number"`
+	Severity string `json:"severity" doc:"Severity of the diagnostic (error or warning)"`
+	Code     string `json:"code" doc:"Tool-specific diagnostic code, e.g. a TypeScript TS#### code"`
+	Message  string `json:"message" doc:"Diagnostic message"`
+}
+
+// GetDiagnosticsResponse represents a response containing a project's latest build diagnostics
+type GetDiagnosticsResponse struct {
+	Body struct {
+		Diagnostics []Diagnostic `json:"diagnostics" doc:"Latest structured diagnostics parsed from dev-server output"`
+	}
+}
+
+// Operation represents a long-running, cancellable operation in flight for a
+// project (lint, build check, dependency install, etc), as returned by
+// GET /projects/{project_id}/operations.
+type Operation struct {
+	ID          string    `json:"id" doc:"Unique identifier for the operation"`
+	Kind        string    `json:"kind" doc:"Operation kind, e.g. lint, check-build, dependencies"`
+	StartedAt   time.Time `json:"started_at" doc:"When the operation began"`
+	CancelToken string    `json:"cancel_token" doc:"Token to DELETE /projects/{project_id}/operations/{cancel_token} with to cancel this operation"`
+}
+
+// ListOperationsResponse represents a response listing a project's in-flight operations
+type ListOperationsResponse struct {
+	Operations []Operation `json:"operations" doc:"Operations currently in flight for the project"`
+}
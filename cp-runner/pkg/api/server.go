@@ -3,29 +3,39 @@ package api
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mime"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/danielgtaylor/huma/v2/sse"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/jjleng/cp-runner/pkg/filesystem"
+	"github.com/jjleng/cp-runner/pkg/gitops"
+	"github.com/jjleng/cp-runner/pkg/hooks"
 	"github.com/jjleng/cp-runner/pkg/project"
 	"github.com/jjleng/cp-runner/pkg/proxy"
+	"github.com/jjleng/cp-runner/pkg/remote"
 	"github.com/jjleng/cp-runner/pkg/runtime"
 )
 
 type ControlPlaneServer struct {
 	orchestrators  map[string]*project.Orchestrator
 	mu             sync.RWMutex
+	gitRepos       map[string]*gitops.Repo
+	gitMu          sync.Mutex
 	packageManager runtime.PackageManager
 	port           int
 	proxyPort      int
@@ -37,66 +47,31 @@ func (s *ControlPlaneServer) getProjectPath(projectID string) string {
 	return filepath.Join(s.workspacePath, projectID)
 }
 
-// execGitCommand executes a git command and returns its output
-func execGitCommand(workingDir string, args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
-	cmd.Dir = workingDir
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("git command failed: %v: %s", err, output)
-	}
-	return string(output), nil
-}
-
-// parseGitLog parses git log output into Commit structs
-func parseGitLog(output string) []Commit {
-	lines := strings.Split(output, "\n")
-	commits := []Commit{}
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		parts := strings.SplitN(line, " ", 5)
-		if len(parts) < 5 {
-			log.Printf("Invalid line format: %s\n", line)
-			continue
-		}
-
-		date := fmt.Sprintf("%s %s %s", parts[0], parts[1], parts[2])
-		hash := parts[3]
-		remaining := parts[4]
-		message := remaining
-
-		if idx := strings.Index(remaining, ")"); idx != -1 {
-			branchStart := strings.Index(remaining, "(")
-			if branchStart != -1 && branchStart < idx {
-				message = strings.TrimSpace(remaining[idx+1:])
-			}
-		}
-
-		if idx := strings.LastIndex(message, " ["); idx != -1 {
-			message = strings.TrimSpace(message[:idx])
-		}
+// getGitRepo returns the gitops.Repo for projectPath, opening and caching it
+// on first use.
+func (s *ControlPlaneServer) getGitRepo(projectPath string) (*gitops.Repo, error) {
+	s.gitMu.Lock()
+	defer s.gitMu.Unlock()
 
-		commits = append(commits, Commit{
-			Hash:    hash,
-			Date:    date,
-			Message: message,
-			Files:   []CommitFile{},
-		})
+	if repo, ok := s.gitRepos[projectPath]; ok {
+		return repo, nil
 	}
 
-	return commits
+	repo, err := gitops.Open(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	s.gitRepos[projectPath] = repo
+	return repo, nil
 }
 
 func NewControlPlaneServer(port int, proxyPort int, pkgManager runtime.PackageManager, workspacePath string) *ControlPlaneServer {
-	if !pkgManager.IsValid() {
+	if pkgManager == nil {
 		pkgManager = runtime.PNPM
 	}
 	return &ControlPlaneServer{
 		orchestrators:  make(map[string]*project.Orchestrator),
+		gitRepos:       make(map[string]*gitops.Repo),
 		packageManager: pkgManager,
 		port:           port,
 		proxyPort:      proxyPort,
@@ -112,6 +87,10 @@ func (s *ControlPlaneServer) getOrchestrator(projectPath string, pkgManager runt
 		return orch
 	}
 
+	if detected, err := runtime.DetectPackageManager(projectPath); err == nil {
+		pkgManager = detected
+	}
+
 	orch := project.NewOrchestrator(projectPath, pkgManager)
 	s.orchestrators[projectPath] = orch
 	return orch
@@ -245,6 +224,59 @@ func (s *ControlPlaneServer) Routes() chi.Router {
 		Errors:      []int{500},
 	}, s.handleCheckBuildErrors)
 
+	sse.Register(api, huma.Operation{
+		OperationID: "lint-project-stream",
+		Method:      http.MethodGet,
+		Path:        "/projects/lint/stream",
+		Summary:     "Stream linting output",
+		Description: "Stream incremental stdout/stderr/exit events from a lint run, attaching to one already in flight if there is one",
+		Tags:        []string{"Projects"},
+	}, map[string]any{
+		"message": StreamEvent{},
+	}, s.handleRunLintStream)
+
+	sse.Register(api, huma.Operation{
+		OperationID: "check-build-errors-stream",
+		Method:      http.MethodGet,
+		Path:        "/projects/build/stream",
+		Summary:     "Stream build check output",
+		Description: "Stream incremental stdout/stderr/exit events from a type check run, attaching to one already in flight if there is one",
+		Tags:        []string{"Projects"},
+	}, map[string]any{
+		"message": StreamEvent{},
+	}, s.handleCheckBuildStream)
+
+	sse.Register(api, huma.Operation{
+		OperationID: "add-package-stream",
+		Method:      http.MethodGet,
+		Path:        "/projects/add-package/stream",
+		Summary:     "Stream package install output",
+		Description: "Stream incremental stdout/stderr/exit events from a package install, attaching to one already in flight if there is one",
+		Tags:        []string{"Projects"},
+	}, map[string]any{
+		"message": StreamEvent{},
+	}, s.handleAddPackageStream)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-project-status",
+		Method:      http.MethodGet,
+		Path:        "/projects/status",
+		Summary:     "Get project dev server status",
+		Description: "Get the dev server's current supervision state (stopped, starting, running, backoff, or fatal)",
+		Tags:        []string{"Projects"},
+		Errors:      []int{400},
+	}, s.handleGetProjectStatus)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "get-diagnostics",
+		Method:      http.MethodGet,
+		Path:        "/projects/diagnostics",
+		Summary:     "Get build diagnostics",
+		Description: "Get the latest structured diagnostics parsed from dev-server output",
+		Tags:        []string{"Projects"},
+		Errors:      []int{400, 500},
+	}, s.handleGetDiagnostics)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "get-file-tree",
 		Method:      http.MethodGet,
@@ -276,6 +308,16 @@ func (s *ControlPlaneServer) Routes() chi.Router {
 		Errors:      []int{400, 500},
 	}, s.handleGetCommits)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "create-commit",
+		Method:      http.MethodPost,
+		Path:        "/git/commits",
+		Summary:     "Create a commit",
+		Description: "Stage changes and create an author-attributed commit",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleCreateCommit)
+
 	huma.Register(api, huma.Operation{
 		OperationID: "get-commit-diff",
 		Method:      http.MethodGet,
@@ -301,15 +343,211 @@ func (s *ControlPlaneServer) Routes() chi.Router {
 		Method:      http.MethodPost,
 		Path:        "/git/commits/switch",
 		Summary:     "Switch to commit",
-		Description: "Switch working directory to a specific commit",
+		Description: "Switch working directory to a specific commit, with a choice of hard/mixed/soft/checkout mode and an optional new branch",
 		Tags:        []string{"Git"},
 		Errors:      []int{400, 500},
 	}, s.handleSwitchCommit)
 
+	huma.Register(api, huma.Operation{
+		OperationID: "list-branches",
+		Method:      http.MethodGet,
+		Path:        "/git/branches",
+		Summary:     "List branches",
+		Description: "List the repository's local branches",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleListBranches)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "create-branch",
+		Method:      http.MethodPost,
+		Path:        "/git/branches",
+		Summary:     "Create branch",
+		Description: "Create a new branch, optionally starting from a specific commit",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleCreateBranch)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "switch-branch",
+		Method:      http.MethodPost,
+		Path:        "/git/branches/switch",
+		Summary:     "Switch branch",
+		Description: "Check out an existing branch",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleSwitchBranch)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-remotes",
+		Method:      http.MethodGet,
+		Path:        "/git/remotes",
+		Summary:     "List remotes",
+		Description: "List the repository's configured remotes",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleListRemotes)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "add-remote",
+		Method:      http.MethodPost,
+		Path:        "/git/remotes",
+		Summary:     "Add remote",
+		Description: "Register a new remote",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handleAddRemote)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "push",
+		Method:      http.MethodPost,
+		Path:        "/git/push",
+		Summary:     "Push",
+		Description: "Push a branch to a remote, publishing the project to GitHub/GitLab/Gitea/etc",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handlePush)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "pull",
+		Method:      http.MethodPost,
+		Path:        "/git/pull",
+		Summary:     "Pull",
+		Description: "Fetch and merge a branch from a remote",
+		Tags:        []string{"Git"},
+		Errors:      []int{400, 500},
+	}, s.handlePull)
+
+	// SSE streams and path-parameterized routes don't fit huma's
+	// request/response typing in this codebase, so they're registered as
+	// plain chi routes alongside the huma-managed API.
+	r.Get("/projects/{project_id}/logs/stream", s.handleLogsStream)
+	r.Get("/projects/{project_id}/operations", s.handleListOperations)
+	r.Delete("/projects/{project_id}/operations/{operation_id}", s.handleCancelOperation)
+	r.Delete("/git/branches/{name}", s.handleDeleteBranch)
+
 	return r
 }
 
-// handleSwitchCommit switches the working directory to a specific commit
+// logsStreamHeartbeat is how often a comment-only SSE event is sent on an
+// idle log stream to keep intermediary proxies from closing the connection.
+const logsStreamHeartbeat = 15 * time.Second
+
+// handleLogsStream streams a project's dev-server stdout/stderr as
+// Server-Sent Events. It replays buffered backlog on connect (resuming from
+// the client-supplied Last-Event-ID if present) and then tails live output
+// until the client disconnects.
+func (s *ControlPlaneServer) handleLogsStream(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "project_id")
+	if projectID == "" {
+		http.Error(w, "project ID is required", http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	orch := s.getOrchestrator(s.getProjectPath(projectID), s.packageManager)
+
+	var (
+		lines       <-chan runtime.LogLine
+		unsubscribe func()
+	)
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if seq, err := strconv.ParseUint(lastEventID, 10, 64); err == nil {
+			lines, unsubscribe = orch.SubscribeLogsAfter(seq)
+		}
+	}
+	if lines == nil {
+		lines, unsubscribe = orch.SubscribeLogs()
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(logsStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(line)
+			if err != nil {
+				log.Printf("Failed to marshal log line: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", line.Seq, data)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// handleListOperations returns the long-running operations (lint, build
+// checks, dependency installs, etc) currently in flight for a project, along
+// with the cancel token each can be cancelled with.
+func (s *ControlPlaneServer) handleListOperations(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "project_id")
+	if projectID == "" {
+		http.Error(w, "project ID is required", http.StatusBadRequest)
+		return
+	}
+
+	orch := s.getOrchestrator(s.getProjectPath(projectID), s.packageManager)
+	ops := orch.ListOperations()
+
+	resp := ListOperationsResponse{}
+	resp.Operations = make([]Operation, len(ops))
+	for i, op := range ops {
+		resp.Operations[i] = Operation{
+			ID:          op.ID,
+			Kind:        op.Kind,
+			StartedAt:   op.StartedAt,
+			CancelToken: op.ID,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to encode operations response: %v", err)
+	}
+}
+
+// handleCancelOperation cancels an in-flight operation by its cancel token.
+func (s *ControlPlaneServer) handleCancelOperation(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "project_id")
+	operationID := chi.URLParam(r, "operation_id")
+	if projectID == "" || operationID == "" {
+		http.Error(w, "project ID and operation ID are required", http.StatusBadRequest)
+		return
+	}
+
+	orch := s.getOrchestrator(s.getProjectPath(projectID), s.packageManager)
+	if !orch.CancelOperation(operationID) {
+		http.Error(w, "operation not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSwitchCommit moves the working directory to a specific commit,
+// using the caller-chosen mode (hard/mixed/soft/checkout) and optionally
+// creating a new branch at that commit instead of moving the current one.
 func (s *ControlPlaneServer) handleSwitchCommit(ctx context.Context, input *SwitchCommitRequest) (*SwitchCommitResponse, error) {
 	projectPath := s.getProjectPath(input.Body.ProjectID)
 	commitHash := input.Body.CommitHash
@@ -319,17 +557,302 @@ func (s *ControlPlaneServer) handleSwitchCommit(ctx context.Context, input *Swit
 		return nil, huma.Error400BadRequest("not a git repository")
 	}
 
-	// Use git reset --hard to reset to the specified commit
-	// This will discard all uncommitted changes and reset the branch pointer
-	if _, err := execGitCommand(projectPath, "reset", "--hard", commitHash); err != nil {
-		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to reset to commit: %v", err), err)
+	mode := gitops.SwitchMode(input.Body.Mode)
+	if mode == "" {
+		mode = gitops.SwitchHard
+	}
+	switch mode {
+	case gitops.SwitchHard, gitops.SwitchMixed, gitops.SwitchSoft, gitops.SwitchCheckout:
+	default:
+		return nil, huma.Error400BadRequest(fmt.Sprintf("invalid mode %q", input.Body.Mode))
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	prevHead, err := repo.Head()
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to resolve current commit: %v", err), err)
+	}
+
+	if err := repo.SwitchCommit(ctx, commitHash, mode, input.Body.CreateBranch, input.Body.Force); err != nil {
+		if errors.Is(err, gitops.ErrDirtyWorkingTree) || errors.Is(err, gitops.ErrBranchExists) {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to switch to commit: %v", err), err)
+	}
+
+	// post-switch always fires, mirroring the control plane's own move of
+	// HEAD; post-checkout only fires when an actual git checkout happened
+	// rather than a reset: for SwitchCheckout, or when a new branch was
+	// created and checked out.
+	var hookResults []hooks.Result
+	hookResults = append(hookResults, hooks.Run(ctx, projectPath, hooks.PostSwitch, prevHead, commitHash, "1")...)
+	if mode == gitops.SwitchCheckout || input.Body.CreateBranch != "" {
+		hookResults = append(hookResults, hooks.Run(ctx, projectPath, hooks.PostCheckout, prevHead, commitHash, "1")...)
 	}
 
 	resp := &SwitchCommitResponse{}
-	resp.Body.Message = fmt.Sprintf("Successfully reset to commit %s", commitHash)
+	if input.Body.CreateBranch != "" {
+		resp.Body.Message = fmt.Sprintf("Created branch %s at commit %s", input.Body.CreateBranch, commitHash)
+	} else {
+		resp.Body.Message = fmt.Sprintf("Successfully switched to commit %s", commitHash)
+	}
+	resp.Body.Hooks = convertHookResults(hookResults)
+	return resp, nil
+}
+
+// handleListBranches returns a project's local branches.
+func (s *ControlPlaneServer) handleListBranches(ctx context.Context, input *ListBranchesRequest) (*ListBranchesResponse, error) {
+	projectPath := s.getProjectPath(input.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	branches, err := repo.ListBranches(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to list branches: %v", err), err)
+	}
+
+	resp := &ListBranchesResponse{}
+	resp.Body.Branches = make([]Branch, len(branches))
+	for i, b := range branches {
+		resp.Body.Branches[i] = Branch{Name: b.Name, IsCurrent: b.IsCurrent}
+	}
+	return resp, nil
+}
+
+// handleCreateBranch creates a new branch, optionally starting from a
+// specific commit instead of HEAD.
+func (s *ControlPlaneServer) handleCreateBranch(ctx context.Context, input *CreateBranchRequest) (*CreateBranchResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	if err := repo.CreateBranch(ctx, input.Body.Name, input.Body.StartCommit); err != nil {
+		if errors.Is(err, gitops.ErrBranchExists) {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to create branch: %v", err), err)
+	}
+
+	resp := &CreateBranchResponse{}
+	resp.Body.Message = fmt.Sprintf("Created branch %s", input.Body.Name)
+	return resp, nil
+}
+
+// handleSwitchBranch checks out an existing branch.
+func (s *ControlPlaneServer) handleSwitchBranch(ctx context.Context, input *SwitchBranchRequest) (*SwitchBranchResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	if err := repo.CheckoutBranch(ctx, input.Body.Name, input.Body.Force); err != nil {
+		if errors.Is(err, gitops.ErrBranchNotFound) {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to switch branch: %v", err), err)
+	}
+
+	resp := &SwitchBranchResponse{}
+	resp.Body.Message = fmt.Sprintf("Switched to branch %s", input.Body.Name)
+	return resp, nil
+}
+
+// handleDeleteBranch deletes a branch by name. Registered as a plain chi
+// route since it carries the branch name as a path parameter, which this
+// codebase's huma usage doesn't support.
+func (s *ControlPlaneServer) handleDeleteBranch(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	projectID := r.URL.Query().Get("project_id")
+	if projectID == "" || name == "" {
+		http.Error(w, "project ID and branch name are required", http.StatusBadRequest)
+		return
+	}
+
+	projectPath := s.getProjectPath(projectID)
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		http.Error(w, "not a git repository", http.StatusBadRequest)
+		return
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to open git repository: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := repo.DeleteBranch(r.Context(), name); err != nil {
+		if errors.Is(err, gitops.ErrBranchNotFound) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to delete branch: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultRemoteName is used by push/pull when the caller doesn't specify one.
+const defaultRemoteName = "origin"
+
+// handleListRemotes returns a project's configured remotes.
+func (s *ControlPlaneServer) handleListRemotes(ctx context.Context, input *ListRemotesRequest) (*ListRemotesResponse, error) {
+	projectPath := s.getProjectPath(input.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	remotes, err := repo.ListRemotes(ctx)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to list remotes: %v", err), err)
+	}
+
+	resp := &ListRemotesResponse{}
+	resp.Body.Remotes = make([]Remote, len(remotes))
+	for i, r := range remotes {
+		resp.Body.Remotes[i] = Remote{Name: r.Name, URLs: r.URLs}
+	}
+	return resp, nil
+}
+
+// handleAddRemote registers a new remote.
+func (s *ControlPlaneServer) handleAddRemote(ctx context.Context, input *AddRemoteRequest) (*AddRemoteResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	if err := repo.AddRemote(ctx, input.Body.Name, input.Body.URL); err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to add remote: %v", err), err)
+	}
+
+	resp := &AddRemoteResponse{}
+	resp.Body.Message = fmt.Sprintf("Added remote %s", input.Body.Name)
 	return resp, nil
 }
 
+// handlePush pushes a branch to a remote, resolving credentials from the
+// request token, the forwarded Authorization header, the user's netrc, or
+// git's configured cookiefile, in that order.
+func (s *ControlPlaneServer) handlePush(ctx context.Context, input *PushRequest) (*PushResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	remoteName := input.Body.Remote
+	if remoteName == "" {
+		remoteName = defaultRemoteName
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	remoteURL, err := repo.RemoteURL(remoteName)
+	if err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+
+	creds, _ := remote.ResolveCredentials(ctx, projectPath, hostFromRemoteURL(remoteURL), input.Body.Token, input.Authorization)
+
+	if err := repo.Push(ctx, remoteName, input.Body.Branch, gitops.BasicAuth(creds), input.Body.Force); err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to push: %v", err), err)
+	}
+
+	resp := &PushResponse{}
+	resp.Body.Message = fmt.Sprintf("Pushed to %s", remoteName)
+	return resp, nil
+}
+
+// handlePull fetches and merges a branch from a remote, resolving
+// credentials the same way handlePush does.
+func (s *ControlPlaneServer) handlePull(ctx context.Context, input *PullRequest) (*PullResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
+
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
+
+	remoteName := input.Body.Remote
+	if remoteName == "" {
+		remoteName = defaultRemoteName
+	}
+
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
+
+	remoteURL, err := repo.RemoteURL(remoteName)
+	if err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+
+	creds, _ := remote.ResolveCredentials(ctx, projectPath, hostFromRemoteURL(remoteURL), input.Body.Token, input.Authorization)
+
+	if err := repo.Pull(ctx, remoteName, input.Body.Branch, gitops.BasicAuth(creds), input.Body.Force); err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to pull: %v", err), err)
+	}
+
+	resp := &PullResponse{}
+	resp.Body.Message = fmt.Sprintf("Pulled from %s", remoteName)
+	return resp, nil
+}
+
+// hostFromRemoteURL extracts the host part of a remote URL, handling both
+// standard URLs (https://host/path) and scp-like syntax (user@host:path).
+func hostFromRemoteURL(raw string) string {
+	if u, err := url.Parse(raw); err == nil && u.Host != "" {
+		return u.Host
+	}
+	if at := strings.Index(raw, "@"); at != -1 {
+		rest := raw[at+1:]
+		if colon := strings.Index(rest, ":"); colon != -1 {
+			return rest[:colon]
+		}
+	}
+	return raw
+}
+
 // handleGetFileTree returns a tree structure of the file system
 func (s *ControlPlaneServer) handleGetFileTree(ctx context.Context, input *GetFileTreeRequest) (*FileSystemResponse, error) {
 	if input.ProjectID == "" {
@@ -340,14 +863,18 @@ func (s *ControlPlaneServer) handleGetFileTree(ctx context.Context, input *GetFi
 	log.Printf("Getting file tree for project at %s\n", projectPath)
 
 	// Build the file tree using the filesystem package
-	tree, err := filesystem.BuildFileTree(projectPath)
+	tree, err := filesystem.BuildFileTreeWithOptions(projectPath, filesystem.Options{
+		RespectGitignore: true,
+		IncludeHidden:    input.IncludeHidden,
+	})
 	if err != nil {
 		return nil, huma.Error500InternalServerError(fmt.Sprintf("Failed to build file tree: %v", err), err)
 	}
 
 	fileNode := FileNode{
-		Name: tree.Name,
-		Type: tree.Type,
+		Name:    tree.Name,
+		Type:    tree.Type,
+		Ignored: tree.Ignored,
 	}
 	if tree.Children != nil {
 		fileNode.Children = make([]FileNode, len(tree.Children))
@@ -365,8 +892,9 @@ func (s *ControlPlaneServer) handleGetFileTree(ctx context.Context, input *GetFi
 // convertFsNode converts a filesystem.Node to a FileNode
 func convertFsNode(node *filesystem.Node) FileNode {
 	fileNode := FileNode{
-		Name: node.Name,
-		Type: node.Type,
+		Name:    node.Name,
+		Type:    node.Type,
+		Ignored: node.Ignored,
 	}
 	if node.Children != nil {
 		fileNode.Children = make([]FileNode, len(node.Children))
@@ -427,6 +955,34 @@ func (s *ControlPlaneServer) handleGetFileContent(ctx context.Context, input *Ge
 	return resp, nil
 }
 
+// Cleanup stops every project the server has ever tracked, regardless of
+// whether it's currently running, so a shutdown doesn't leave a dev server
+// that's mid-backoff (and so not "running" by stopAllProjects's definition)
+// orphaned. Intended to be called once, as the process is exiting.
+func (s *ControlPlaneServer) Cleanup() {
+	s.mu.RLock()
+	orchestrators := make([]*project.Orchestrator, 0, len(s.orchestrators))
+	for _, orch := range s.orchestrators {
+		orchestrators = append(orchestrators, orch)
+	}
+	s.mu.RUnlock()
+
+	for _, orch := range orchestrators {
+		orch.Cleanup()
+	}
+}
+
+// ForceKillAll immediately SIGKILLs every tracked project's dev server
+// process group, without waiting for a graceful exit. Used to escalate out
+// of a Cleanup call that's hanging, e.g. on a second shutdown signal.
+func (s *ControlPlaneServer) ForceKillAll() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, orch := range s.orchestrators {
+		orch.ForceKill()
+	}
+}
+
 func (s *ControlPlaneServer) stopAllProjects() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -460,7 +1016,7 @@ func (s *ControlPlaneServer) handleStartProject(ctx context.Context, input *Proj
 
 	orch := s.getOrchestrator(projectPath, s.packageManager)
 
-	if err := orch.RestartProject(s.proxyPort, appPort); err != nil {
+	if err := orch.RestartProject(ctx, s.proxyPort, appPort); err != nil {
 		return nil, huma.Error500InternalServerError(fmt.Sprintf("Failed to start project: %v", err), err)
 	}
 
@@ -486,7 +1042,7 @@ func (s *ControlPlaneServer) handleStopProject(ctx context.Context, input *Proje
 func (s *ControlPlaneServer) handleRunLint(ctx context.Context, input *ProjectOperationRequest) (*LintResponse, error) {
 	projectPath := s.getProjectPath(input.Body.ProjectID)
 	orch := s.getOrchestrator(projectPath, s.packageManager)
-	success, output := orch.RunLint()
+	success, output := orch.RunLint(ctx)
 
 	resp := &LintResponse{}
 	resp.Body.Message = output
@@ -494,10 +1050,51 @@ func (s *ControlPlaneServer) handleRunLint(ctx context.Context, input *ProjectOp
 	return resp, nil
 }
 
+// handleGetProjectStatus returns the dev server's current supervision state,
+// so the frontend can render a restarting/fatal page instead of treating a
+// connection refused as a generic error.
+func (s *ControlPlaneServer) handleGetProjectStatus(ctx context.Context, input *GetProjectStatusRequest) (*GetProjectStatusResponse, error) {
+	if input.ProjectID == "" {
+		return nil, huma.Error400BadRequest("project ID is required")
+	}
+
+	orch := s.getOrchestrator(s.getProjectPath(input.ProjectID), s.packageManager)
+
+	resp := &GetProjectStatusResponse{}
+	resp.Body.State = orch.State().String()
+	resp.Body.LastFailure = orch.LastFailure()
+	return resp, nil
+}
+
+// handleGetDiagnostics returns the latest structured diagnostics parsed from
+// the project's dev-server output.
+func (s *ControlPlaneServer) handleGetDiagnostics(ctx context.Context, input *GetDiagnosticsRequest) (*GetDiagnosticsResponse, error) {
+	if input.ProjectID == "" {
+		return nil, huma.Error400BadRequest("project ID is required")
+	}
+
+	orch := s.getOrchestrator(s.getProjectPath(input.ProjectID), s.packageManager)
+	diagnostics := orch.LatestDiagnostics()
+
+	resp := &GetDiagnosticsResponse{}
+	resp.Body.Diagnostics = make([]Diagnostic, len(diagnostics))
+	for i, d := range diagnostics {
+		resp.Body.Diagnostics[i] = Diagnostic{
+			File:     d.File,
+			Line:     d.Line,
+			Col:      d.Col,
+			Severity: string(d.Severity),
+			Code:     d.Code,
+			Message:  d.Message,
+		}
+	}
+	return resp, nil
+}
+
 func (s *ControlPlaneServer) handleCheckBuildErrors(ctx context.Context, input *ProjectOperationRequest) (*BuildErrorResponse, error) {
 	projectPath := s.getProjectPath(input.Body.ProjectID)
 	orch := s.getOrchestrator(projectPath, s.packageManager)
-	success, output := orch.CheckBuildErrors()
+	success, output := orch.CheckBuildErrors(ctx)
 
 	resp := &BuildErrorResponse{}
 	resp.Body.Message = output
@@ -505,6 +1102,47 @@ func (s *ControlPlaneServer) handleCheckBuildErrors(ctx context.Context, input *
 	return resp, nil
 }
 
+// handleRunLintStream streams a lint run's stdout/stderr/exit events as
+// they happen, attaching to one already in flight if there is one.
+func (s *ControlPlaneServer) handleRunLintStream(ctx context.Context, input *RunLintStreamRequest, send sse.Sender) {
+	orch := s.getOrchestrator(s.getProjectPath(input.ProjectID), s.packageManager)
+	events, unsubscribe := orch.RunLintStream(ctx)
+	defer unsubscribe()
+	streamOperation(ctx, events, send)
+}
+
+// handleCheckBuildStream streams a type check run's stdout/stderr/exit
+// events the same way handleRunLintStream does.
+func (s *ControlPlaneServer) handleCheckBuildStream(ctx context.Context, input *CheckBuildStreamRequest, send sse.Sender) {
+	orch := s.getOrchestrator(s.getProjectPath(input.ProjectID), s.packageManager)
+	events, unsubscribe := orch.CheckBuildErrorsStream(ctx)
+	defer unsubscribe()
+	streamOperation(ctx, events, send)
+}
+
+// streamOperation relays events from a runtime.StreamEvent subscription to
+// an SSE sender until the run exits, the client disconnects, or a send
+// fails. It reports whether the run's exit event, if one was delivered,
+// reported success (exit code 0).
+func streamOperation(ctx context.Context, events <-chan runtime.StreamEvent, send sse.Sender) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if err := send.Data(StreamEvent{Type: string(event.Type), Data: event.Data, Code: event.Code}); err != nil {
+				return false
+			}
+			if event.Type == runtime.StreamEventExit {
+				return event.Code == 0
+			}
+		}
+	}
+}
+
 // handleGetCommits returns the commit history of a repository
 func (s *ControlPlaneServer) handleGetCommits(ctx context.Context, input *GetCommitsRequest) (*GetCommitsResponse, error) {
 	projectPath := s.getProjectPath(input.ProjectID)
@@ -519,91 +1157,97 @@ func (s *ControlPlaneServer) handleGetCommits(ctx context.Context, input *GetCom
 		limit = 20
 	}
 
-	// Fetch all commits, we'll manually handle pagination
-	args := []string{"--no-pager", "log", "--pretty=format:%ai %H %d %s [%an]"}
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
+	}
 
-	// Get git log with commit info
-	output, err := execGitCommand(projectPath, args...)
+	commits, hasNextPage, err := repo.Log(ctx, limit, input.Cursor)
 	if err != nil {
 		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to get git log: %v", err), err)
 	}
 
-	// Parse git log output into structured commits
-	allCommits := parseGitLog(output)
-
-	// Find starting index based on cursor
-	startIndex := 0
-	if input.Cursor != "" {
-		for i, commit := range allCommits {
-			if commit.Hash == input.Cursor {
-				startIndex = i + 1 // Start from the commit after the cursor
-				break
-			}
-		}
+	resp := &GetCommitsResponse{}
+	resp.Body.Commits = make([]Commit, len(commits))
+	for i, c := range commits {
+		resp.Body.Commits[i] = convertCommit(c)
+	}
+	resp.Body.HasNextPage = hasNextPage
+	if hasNextPage && len(commits) > 0 {
+		resp.Body.NextCursor = commits[len(commits)-1].Hash
 	}
+	return resp, nil
+}
 
-	// Slice the commits to handle pagination
-	endIndex := min(startIndex+limit, len(allCommits))
+// handleCreateCommit stages changes and creates an author-attributed commit.
+func (s *ControlPlaneServer) handleCreateCommit(ctx context.Context, input *CreateCommitRequest) (*CreateCommitResponse, error) {
+	projectPath := s.getProjectPath(input.Body.ProjectID)
 
-	// Check if we have more pages
-	hasNextPage := endIndex < len(allCommits)
+	if _, err := os.Stat(filepath.Join(projectPath, ".git")); os.IsNotExist(err) {
+		return nil, huma.Error400BadRequest("not a git repository")
+	}
 
-	// Get the page of commits
-	var pageCommits []Commit
-	if startIndex < len(allCommits) {
-		pageCommits = allCommits[startIndex:endIndex]
-	} else {
-		pageCommits = []Commit{}
+	repo, err := s.getGitRepo(projectPath)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
 	}
 
-	// Get files changed for each commit in this page
-	for i := range pageCommits {
-		// Get status of files in commit
-		filesOutput, err := execGitCommand(projectPath, "show", "--name-status", "--pretty=format:", pageCommits[i].Hash)
-		if err != nil {
-			return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to get changed files: %v", err), err)
+	result, err := repo.Commit(ctx, input.Body.Paths, input.Body.Message, gitops.Author{
+		Name:  input.Body.AuthorName,
+		Email: input.Body.AuthorEmail,
+	}, input.Body.AllowEmpty, input.Body.DryRun)
+	if err != nil {
+		if errors.Is(err, gitops.ErrEmptyMessage) || errors.Is(err, gitops.ErrCleanWorkingTree) {
+			return nil, huma.Error400BadRequest(err.Error())
 		}
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to create commit: %v", err), err)
+	}
 
-		// Parse changed files list with status
-		files := strings.Split(strings.TrimSpace(filesOutput), "\n")
-		for _, file := range files {
-			if file == "" {
-				continue
-			}
-			parts := strings.Fields(file)
-			if len(parts) < 2 {
-				continue
-			}
+	resp := &CreateCommitResponse{}
+	resp.Body.Hash = result.Hash
+	resp.Body.Files = result.Files
+	resp.Body.DryRun = result.DryRun
+	if !result.DryRun {
+		resp.Body.Hooks = convertHookResults(hooks.Run(ctx, projectPath, hooks.PostCommit))
+	}
+	return resp, nil
+}
 
-			status := parts[0]
-			path := parts[1]
-			fileType := ""
-
-			switch status {
-			case "A":
-				fileType = "added"
-			case "M":
-				fileType = "modified"
-			case "D":
-				fileType = "deleted"
-			default:
-				fileType = "modified"
-			}
+// convertHookResults maps hooks.Result values onto the API's HookResult DTO.
+func convertHookResults(results []hooks.Result) []HookResult {
+	out := make([]HookResult, len(results))
+	for i, r := range results {
+		out[i] = HookResult{Name: r.Name, ExitCode: r.ExitCode, Output: r.Output}
+	}
+	return out
+}
 
-			pageCommits[i].Files = append(pageCommits[i].Files, CommitFile{
-				Path: path,
-				Type: fileType,
-			})
+// convertCommit maps a gitops.Commit onto the API's Commit DTO.
+func convertCommit(c gitops.Commit) Commit {
+	files := make([]CommitFile, len(c.Files))
+	for i, f := range c.Files {
+		files[i] = CommitFile{
+			Path:    f.Path,
+			OldPath: f.OldPath,
+			Type:    string(f.Type),
 		}
 	}
+	return Commit{
+		Hash:    c.Hash,
+		Message: c.Message,
+		Date:    c.Date,
+		Files:   files,
+	}
+}
 
-	resp := &GetCommitsResponse{}
-	resp.Body.Commits = pageCommits
-	resp.Body.HasNextPage = hasNextPage
-	if hasNextPage && len(pageCommits) > 0 {
-		resp.Body.NextCursor = pageCommits[len(pageCommits)-1].Hash
+// convertFileDiff maps a gitops.FileDiff onto the API's FileDiff DTO.
+func convertFileDiff(d gitops.FileDiff) FileDiff {
+	return FileDiff{
+		Path:     d.Path,
+		OldText:  d.OldText,
+		NewText:  d.NewText,
+		IsBinary: d.IsBinary,
 	}
-	return resp, nil
 }
 
 // handleGetCommitDiff returns the diff for a specific commit
@@ -616,38 +1260,19 @@ func (s *ControlPlaneServer) handleGetCommitDiff(ctx context.Context, input *Get
 		return nil, huma.Error400BadRequest("not a git repository")
 	}
 
-	// Get list of changed files
-	filesOutput, err := execGitCommand(projectPath, "show", "--name-only", "--pretty=format:", commitHash)
+	repo, err := s.getGitRepo(projectPath)
 	if err != nil {
-		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to get changed files: %v", err), err)
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
 	}
 
-	files := strings.Split(strings.TrimSpace(filesOutput), "\n")
-	changes := make([]FileDiff, 0, len(files))
-
-	// Get diff for each changed file
-	for _, file := range files {
-		if file == "" {
-			continue
-		}
-
-		// Get old version (before commit)
-		oldContent, err := execGitCommand(projectPath, "show", fmt.Sprintf("%s^:%s", commitHash, file))
-		if err != nil {
-			oldContent = "" // File might be new
-		}
-
-		// Get new version (at commit)
-		newContent, err := execGitCommand(projectPath, "show", fmt.Sprintf("%s:%s", commitHash, file))
-		if err != nil {
-			newContent = "" // File might be deleted
-		}
+	diffs, err := repo.CommitDiff(ctx, commitHash)
+	if err != nil {
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to get commit diff: %v", err), err)
+	}
 
-		changes = append(changes, FileDiff{
-			Path:    file,
-			OldText: oldContent,
-			NewText: newContent,
-		})
+	changes := make([]FileDiff, len(diffs))
+	for i, d := range diffs {
+		changes[i] = convertFileDiff(d)
 	}
 
 	resp := &GetCommitDiffResponse{}
@@ -659,12 +1284,18 @@ func (s *ControlPlaneServer) handleAddPackage(ctx context.Context, input *AddPac
 	projectPath := s.getProjectPath(input.Body.ProjectID)
 	orch := s.getOrchestrator(projectPath, s.packageManager)
 
-	success, output := orch.AddPackage(input.Body.PackageName)
+	success, output := orch.AddPackage(ctx, input.Body.PackageName, input.Body.Dev)
 
 	resp := &AddPackageResponse{}
 	resp.Body.Success = success
 	resp.Body.Message = output
 
+	// Installing a dependency merges external code into the tree, so it
+	// fires post-merge rather than a dedicated hook of its own.
+	if success {
+		resp.Body.Hooks = convertHookResults(hooks.Run(ctx, projectPath, hooks.PostMerge, "0"))
+	}
+
 	// If package added successfully and restart is requested, restart the project
 	if success && input.Body.RestartServer {
 		// Stop all running projects to free up the proxy port
@@ -678,7 +1309,7 @@ func (s *ControlPlaneServer) handleAddPackage(ctx context.Context, input *AddPac
 			return resp, nil
 		}
 
-		if err := orch.RestartProject(s.proxyPort, appPort); err != nil {
+		if err := orch.RestartProject(ctx, s.proxyPort, appPort); err != nil {
 			log.Printf("Failed to restart project after adding package: %v", err)
 			resp.Body.Message += "\nWarning: Failed to restart server after package installation."
 		} else {
@@ -691,6 +1322,24 @@ func (s *ControlPlaneServer) handleAddPackage(ctx context.Context, input *AddPac
 	return resp, nil
 }
 
+// handleAddPackageStream streams a package install's stdout/stderr/exit
+// events as they happen, attaching to one already in flight if there is
+// one. Unlike handleAddPackage, it never restarts the server afterwards.
+func (s *ControlPlaneServer) handleAddPackageStream(ctx context.Context, input *AddPackageStreamRequest, send sse.Sender) {
+	projectPath := s.getProjectPath(input.ProjectID)
+	orch := s.getOrchestrator(projectPath, s.packageManager)
+	events, unsubscribe := orch.AddPackageStream(ctx, input.PackageName, input.Dev)
+	defer unsubscribe()
+	success := streamOperation(ctx, events, send)
+
+	// Installing a dependency merges external code into the tree, so it
+	// fires post-merge rather than a dedicated hook of its own, same as
+	// handleAddPackage.
+	if success {
+		hooks.Run(ctx, projectPath, hooks.PostMerge, "0")
+	}
+}
+
 // handleGetFileDiff returns the diff for a specific file in a commit
 func (s *ControlPlaneServer) handleGetFileDiff(ctx context.Context, input *GetFileDiffRequest) (*GetFileDiffResponse, error) {
 	projectPath := s.getProjectPath(input.ProjectID)
@@ -702,23 +1351,17 @@ func (s *ControlPlaneServer) handleGetFileDiff(ctx context.Context, input *GetFi
 		return nil, huma.Error400BadRequest("not a git repository")
 	}
 
-	// Get old version (before commit)
-	oldContent, err := execGitCommand(projectPath, "show", fmt.Sprintf("%s^:%s", commitHash, filePath))
+	repo, err := s.getGitRepo(projectPath)
 	if err != nil {
-		oldContent = "" // File might be new
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to open git repository: %v", err), err)
 	}
 
-	// Get new version (at commit)
-	newContent, err := execGitCommand(projectPath, "show", fmt.Sprintf("%s:%s", commitHash, filePath))
+	diff, err := repo.FileDiff(ctx, commitHash, filePath)
 	if err != nil {
-		newContent = "" // File might be deleted
+		return nil, huma.Error500InternalServerError(fmt.Sprintf("failed to get file diff: %v", err), err)
 	}
 
 	resp := &GetFileDiffResponse{}
-	resp.Body.Diff = FileDiff{
-		Path:    filePath,
-		OldText: oldContent,
-		NewText: newContent,
-	}
+	resp.Body.Diff = convertFileDiff(diff)
 	return resp, nil
 }
@@ -0,0 +1,101 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Options configures BuildFileTreeWithOptions.
+type Options struct {
+	// ExtraIgnore is additional gitignore-style patterns applied on top of
+	// any .gitignore files found plus the root's optional .cpignore,
+	// relative to the tree's root.
+	ExtraIgnore []string
+
+	// RespectGitignore walks each directory's .gitignore, plus the root's
+	// optional .cpignore and ExtraIgnore, flagging matching entries as
+	// ignored instead of just including them unconditionally.
+	RespectGitignore bool
+
+	// IncludeHidden includes dotfiles and dotdirs in the tree. When false,
+	// they're excluded entirely rather than merely flagged ignored.
+	IncludeHidden bool
+}
+
+// ignoreLayer is one directory's compiled ignore patterns, scoped to
+// everything under baseDir.
+type ignoreLayer struct {
+	baseDir string
+	matcher *ignore.GitIgnore
+}
+
+func (l *ignoreLayer) matches(path string, isDir bool) (matched, applies bool) {
+	rel, err := filepath.Rel(l.baseDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	if isDir {
+		rel += "/"
+	}
+	matched, pattern := l.matcher.MatchesPathHow(rel)
+	return matched, pattern != nil
+}
+
+// layeredIgnore decides whether a path is ignored by checking every
+// applicable directory's .gitignore from root to leaf, then the root's
+// .cpignore/ExtraIgnore last - the same precedence git itself gives a more
+// specific, nested .gitignore over a parent one.
+type layeredIgnore struct {
+	layers []ignoreLayer
+	extra  *ignoreLayer
+}
+
+// withLayer returns a layeredIgnore that additionally applies dir's own
+// .gitignore (if it has one) to paths under dir, for use while recursing
+// into it.
+func (li *layeredIgnore) withLayer(dir string) *layeredIgnore {
+	matcher, err := ignore.CompileIgnoreFile(filepath.Join(dir, ".gitignore"))
+	if err != nil || matcher == nil {
+		return li
+	}
+	layers := make([]ignoreLayer, len(li.layers), len(li.layers)+1)
+	copy(layers, li.layers)
+	layers = append(layers, ignoreLayer{baseDir: dir, matcher: matcher})
+	return &layeredIgnore{layers: layers, extra: li.extra}
+}
+
+// isIgnored reports whether path is ignored, applying layers in root-to-leaf
+// order so the most specific rule decides, then extra (.cpignore plus
+// ExtraIgnore) with the final say.
+func (li *layeredIgnore) isIgnored(path string, isDir bool) bool {
+	ignored := false
+	for _, layer := range li.layers {
+		if matched, applies := layer.matches(path, isDir); applies {
+			ignored = matched
+		}
+	}
+	if li.extra != nil {
+		if matched, applies := li.extra.matches(path, isDir); applies {
+			ignored = matched
+		}
+	}
+	return ignored
+}
+
+// newExtraIgnore compiles opts.ExtraIgnore plus root's optional .cpignore
+// into a single ignore layer scoped to root. Returns nil if there's nothing
+// to compile.
+func newExtraIgnore(root string, opts Options) *ignoreLayer {
+	lines := append([]string{}, opts.ExtraIgnore...)
+	if content, err := os.ReadFile(filepath.Join(root, ".cpignore")); err == nil {
+		lines = append(lines, strings.Split(string(content), "\n")...)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return &ignoreLayer{baseDir: root, matcher: ignore.CompileIgnoreLines(lines...)}
+}
@@ -0,0 +1,166 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+func TestIgnoreLayerMatches(t *testing.T) {
+	root := t.TempDir()
+	layer := ignoreLayer{baseDir: root, matcher: ignore.CompileIgnoreLines("*.log", "build/")}
+
+	tests := []struct {
+		name        string
+		path        string
+		isDir       bool
+		wantMatched bool
+		wantApplies bool
+	}{
+		{"file matches pattern", filepath.Join(root, "debug.log"), false, true, true},
+		{"file does not match", filepath.Join(root, "main.go"), false, false, false},
+		{"directory-only pattern matches dir", filepath.Join(root, "build"), true, true, true},
+		{"directory-only pattern does not match file named build", filepath.Join(root, "build"), false, false, false},
+		{"path outside baseDir", filepath.Join(t.TempDir(), "debug.log"), false, false, false},
+		{"baseDir itself", root, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, applies := layer.matches(tt.path, tt.isDir)
+			if matched != tt.wantMatched || applies != tt.wantApplies {
+				t.Errorf("matches(%q, %v) = (%v, %v), want (%v, %v)", tt.path, tt.isDir, matched, applies, tt.wantMatched, tt.wantApplies)
+			}
+		})
+	}
+}
+
+func TestLayeredIgnoreIsIgnoredSingleLayer(t *testing.T) {
+	root := t.TempDir()
+	li := &layeredIgnore{layers: []ignoreLayer{
+		{baseDir: root, matcher: ignore.CompileIgnoreLines("*.log")},
+	}}
+
+	if !li.isIgnored(filepath.Join(root, "debug.log"), false) {
+		t.Errorf("debug.log should be ignored")
+	}
+	if li.isIgnored(filepath.Join(root, "main.go"), false) {
+		t.Errorf("main.go should not be ignored")
+	}
+}
+
+func TestLayeredIgnoreNestedLayerOverridesParent(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+
+	// The nested layer's own matcher includes both the broad rule and its
+	// own negation, which is how go-gitignore's negation semantics work:
+	// negating a pattern only undoes a match made earlier within the same
+	// compiled matcher, not one from a different (parent) layer.
+	li := &layeredIgnore{layers: []ignoreLayer{
+		{baseDir: root, matcher: ignore.CompileIgnoreLines("*.log")},
+		{baseDir: sub, matcher: ignore.CompileIgnoreLines("*.log", "!keep.log")},
+	}}
+
+	if !li.isIgnored(filepath.Join(sub, "debug.log"), false) {
+		t.Errorf("sub/debug.log should be ignored (inherited from root layer)")
+	}
+	if li.isIgnored(filepath.Join(sub, "keep.log"), false) {
+		t.Errorf("sub/keep.log should not be ignored (nested layer re-includes it)")
+	}
+
+	// A file outside the nested layer's baseDir only sees the root layer.
+	if !li.isIgnored(filepath.Join(root, "other.log"), false) {
+		t.Errorf("root/other.log should be ignored (nested layer doesn't apply)")
+	}
+}
+
+func TestLayeredIgnoreExtraHasFinalSay(t *testing.T) {
+	root := t.TempDir()
+	li := &layeredIgnore{
+		layers: []ignoreLayer{
+			{baseDir: root, matcher: ignore.CompileIgnoreLines("*.log")},
+		},
+		extra: &ignoreLayer{baseDir: root, matcher: ignore.CompileIgnoreLines("secrets.env")},
+	}
+
+	if !li.isIgnored(filepath.Join(root, "debug.log"), false) {
+		t.Errorf("debug.log should be ignored via layer")
+	}
+	if !li.isIgnored(filepath.Join(root, "secrets.env"), false) {
+		t.Errorf("secrets.env should be ignored via extra")
+	}
+	if li.isIgnored(filepath.Join(root, "main.go"), false) {
+		t.Errorf("main.go should not be ignored")
+	}
+}
+
+func TestLayeredIgnoreWithLayer(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".gitignore"), []byte("*.tmp\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	li := &layeredIgnore{}
+	withSub := li.withLayer(sub)
+	if len(withSub.layers) != 1 {
+		t.Fatalf("len(layers) = %d, want 1", len(withSub.layers))
+	}
+	if !withSub.isIgnored(filepath.Join(sub, "cache.tmp"), false) {
+		t.Errorf("sub/cache.tmp should be ignored per sub's .gitignore")
+	}
+
+	// A directory with no .gitignore leaves the layeredIgnore unchanged.
+	noGitignoreDir := filepath.Join(root, "plain")
+	if err := os.Mkdir(noGitignoreDir, 0o755); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+	unchanged := li.withLayer(noGitignoreDir)
+	if len(unchanged.layers) != 0 {
+		t.Errorf("len(layers) = %d, want 0 for a directory without .gitignore", len(unchanged.layers))
+	}
+}
+
+func TestNewExtraIgnore(t *testing.T) {
+	t.Run("nothing to compile", func(t *testing.T) {
+		root := t.TempDir()
+		if got := newExtraIgnore(root, Options{}); got != nil {
+			t.Errorf("newExtraIgnore() = %v, want nil", got)
+		}
+	})
+
+	t.Run("ExtraIgnore only", func(t *testing.T) {
+		root := t.TempDir()
+		layer := newExtraIgnore(root, Options{ExtraIgnore: []string{"*.secret"}})
+		if layer == nil {
+			t.Fatal("newExtraIgnore() = nil, want non-nil")
+		}
+		matched, applies := layer.matches(filepath.Join(root, "creds.secret"), false)
+		if !matched || !applies {
+			t.Errorf("matches(creds.secret) = (%v, %v), want (true, true)", matched, applies)
+		}
+	})
+
+	t.Run(".cpignore merged with ExtraIgnore", func(t *testing.T) {
+		root := t.TempDir()
+		if err := os.WriteFile(filepath.Join(root, ".cpignore"), []byte("*.log\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		layer := newExtraIgnore(root, Options{ExtraIgnore: []string{"*.secret"}})
+		if layer == nil {
+			t.Fatal("newExtraIgnore() = nil, want non-nil")
+		}
+		if matched, _ := layer.matches(filepath.Join(root, "debug.log"), false); !matched {
+			t.Errorf(".cpignore pattern not applied")
+		}
+		if matched, _ := layer.matches(filepath.Join(root, "creds.secret"), false); !matched {
+			t.Errorf("ExtraIgnore pattern not applied")
+		}
+	})
+}
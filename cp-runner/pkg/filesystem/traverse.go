@@ -10,6 +10,7 @@ import (
 type Node struct {
 	Name     string  `json:"name"`
 	Type     string  `json:"type"` // "file" or "folder"
+	Ignored  bool    `json:"ignored,omitempty"`
 	Children []*Node `json:"children,omitempty"`
 }
 
@@ -40,40 +41,85 @@ func skipDirectory(name string) bool {
 	return false
 }
 
-// BuildFileTree creates a tree structure of the file system starting from the given path
+// SkipDirectory reports whether a directory named name should be excluded
+// from a tree walk, using the same skip list BuildFileTree does. Exported
+// so other packages that walk a project's tree (e.g. the live-reload file
+// watcher) stay consistent with it.
+func SkipDirectory(name string) bool {
+	return skipDirectory(name)
+}
+
+// BuildFileTree creates a tree structure of the file system starting from
+// root, respecting .gitignore files and hiding dotfiles. It's a thin
+// wrapper over BuildFileTreeWithOptions kept for backward compatibility.
 func BuildFileTree(root string) (*Node, error) {
-	info, err := os.Stat(root)
+	return BuildFileTreeWithOptions(root, Options{RespectGitignore: true})
+}
+
+// BuildFileTreeWithOptions is like BuildFileTree but lets the caller
+// control .gitignore/.cpignore handling and whether hidden files are
+// included. Entries matched by an ignore rule are still included in the
+// tree, flagged Ignored, so the frontend can render them dimmed rather
+// than have them silently vanish; an ignored directory's contents aren't
+// walked, though, since that could mean descending into an enormous
+// node_modules or build output for no benefit.
+func BuildFileTreeWithOptions(root string, opts Options) (*Node, error) {
+	var ig *layeredIgnore
+	if opts.RespectGitignore {
+		ig = &layeredIgnore{extra: newExtraIgnore(root, opts)}
+	}
+	return buildNode(root, opts, ig)
+}
+
+func buildNode(path string, opts Options, ig *layeredIgnore) (*Node, error) {
+	info, err := os.Stat(path)
 	if err != nil {
 		return nil, err
 	}
 
-	baseName := filepath.Base(root)
-	if info.IsDir() && skipDirectory(baseName) {
+	baseName := filepath.Base(path)
+	if baseName == ".git" {
 		return nil, nil
 	}
-
-	node := &Node{
-		Name: baseName,
-		Type: "folder",
+	if !opts.IncludeHidden && strings.HasPrefix(baseName, ".") {
+		return nil, nil
 	}
 
+	node := &Node{Name: baseName, Type: "folder"}
 	if !info.IsDir() {
 		node.Type = "file"
+	}
+
+	// skipDirectory is an always-applied floor, independent of whether a
+	// .gitignore/.cpignore exists or is complete - it's what keeps a
+	// freshly scaffolded project (no .gitignore yet, or one that hasn't
+	// caught up) from walking all of node_modules.
+	ignored := info.IsDir() && skipDirectory(baseName)
+	if ig != nil && ig.isIgnored(path, info.IsDir()) {
+		ignored = true
+	}
+	node.Ignored = ignored
+
+	if !info.IsDir() || node.Ignored {
 		return node, nil
 	}
 
-	entries, err := os.ReadDir(root)
+	childIgnore := ig
+	if ig != nil {
+		childIgnore = ig.withLayer(path)
+	}
+
+	entries, err := os.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, entry := range entries {
-		fullPath := filepath.Join(root, entry.Name())
-		child, err := BuildFileTree(fullPath)
+		child, err := buildNode(filepath.Join(path, entry.Name()), opts, childIgnore)
 		if err != nil {
-			continue // Skip files that can't be accessed
+			continue // Skip entries that can't be accessed
 		}
-		if child != nil { // Only append if child is not nil (not skipped)
+		if child != nil {
 			node.Children = append(node.Children, child)
 		}
 	}
@@ -0,0 +1,137 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ErrBranchExists is returned by CreateBranch and SwitchCommit (when
+// creating a branch) if a branch by that name already exists.
+var ErrBranchExists = errors.New("branch already exists")
+
+// ErrBranchNotFound is returned by CheckoutBranch and DeleteBranch when the
+// named branch doesn't exist.
+var ErrBranchNotFound = errors.New("branch not found")
+
+// Branch is a single local branch.
+type Branch struct {
+	Name      string
+	IsCurrent bool
+}
+
+// ListBranches returns every local branch, with IsCurrent set on the one
+// HEAD currently points to.
+func (r *Repo) ListBranches(ctx context.Context) ([]Branch, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	head, err := r.repo.Head()
+	if err != nil && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	iter, err := r.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	defer iter.Close()
+
+	var branches []Branch
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, Branch{
+			Name:      ref.Name().Short(),
+			IsCurrent: head != nil && head.Name() == ref.Name(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	return branches, nil
+}
+
+// CreateBranch creates a new branch named name pointing at the commit
+// identified by startHash, or at HEAD if startHash is empty.
+func (r *Repo) CreateBranch(ctx context.Context, name, startHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.createBranchAt(name, startHash)
+}
+
+// createBranchAt creates branch name pointing at hash (or HEAD if hash is
+// empty) without moving the working tree.
+func (r *Repo) createBranchAt(name, hash string) error {
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := r.repo.Reference(refName, false); err == nil {
+		return ErrBranchExists
+	}
+
+	target := plumbing.NewHash(hash)
+	if hash == "" {
+		head, err := r.repo.Head()
+		if err != nil {
+			return fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		target = head.Hash()
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(refName, target)); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	if err := r.repo.CreateBranch(&config.Branch{Name: name}); err != nil {
+		return fmt.Errorf("failed to record branch config for %s: %w", name, err)
+	}
+	return nil
+}
+
+// CheckoutBranch switches the working tree to branch name.
+func (r *Repo) CheckoutBranch(ctx context.Context, name string, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := r.repo.Reference(refName, false); err != nil {
+		return ErrBranchNotFound
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+	if err := wt.Checkout(&git.CheckoutOptions{Branch: refName, Force: force}); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteBranch removes branch name. It's an error to delete the currently
+// checked-out branch.
+func (r *Repo) DeleteBranch(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(name)
+	if _, err := r.repo.Reference(refName, false); err != nil {
+		return ErrBranchNotFound
+	}
+
+	if head, err := r.repo.Head(); err == nil && head.Name() == refName {
+		return fmt.Errorf("cannot delete the currently checked-out branch %s", name)
+	}
+
+	if err := r.repo.Storer.RemoveReference(refName); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", name, err)
+	}
+	// Branch config (upstream tracking, etc) is optional; ignore if absent.
+	_ = r.repo.DeleteBranch(name)
+	return nil
+}
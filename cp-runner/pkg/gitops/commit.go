@@ -0,0 +1,147 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrEmptyMessage is returned by Commit when message is blank.
+var ErrEmptyMessage = errors.New("commit message must not be empty")
+
+// ErrCleanWorkingTree is returned by Commit when there's nothing staged and
+// allowEmpty is false.
+var ErrCleanWorkingTree = errors.New("working tree is clean, nothing to commit")
+
+// Author identifies who a commit is attributed to.
+type Author struct {
+	Name  string
+	Email string
+}
+
+// CommitResult describes the outcome of a Commit call.
+type CommitResult struct {
+	// Hash is the new commit's hash. Empty when DryRun is true.
+	Hash string
+	// Files lists the paths staged for the commit.
+	Files []string
+	// DryRun is true if no commit was actually written.
+	DryRun bool
+}
+
+// Commit stages paths (or, if empty, every modified/added/deleted file in
+// the working tree) and creates a commit attributed to author. If dryRun is
+// true, the files that would be included are computed and returned without
+// writing a commit. If allowEmpty is false, a clean working tree (nothing
+// staged) returns ErrCleanWorkingTree instead of creating an empty commit.
+func (r *Repo) Commit(ctx context.Context, paths []string, message string, author Author, allowEmpty, dryRun bool) (CommitResult, error) {
+	if err := ctx.Err(); err != nil {
+		return CommitResult{}, err
+	}
+
+	if strings.TrimSpace(message) == "" {
+		return CommitResult{}, ErrEmptyMessage
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return CommitResult{}, fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if dryRun {
+		staged, err := dryRunStaged(wt, paths)
+		if err != nil {
+			return CommitResult{}, err
+		}
+		if len(staged) == 0 && !allowEmpty {
+			return CommitResult{}, ErrCleanWorkingTree
+		}
+		return CommitResult{Files: staged, DryRun: true}, nil
+	}
+
+	if len(paths) == 0 {
+		if err := wt.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			return CommitResult{}, fmt.Errorf("failed to stage changes: %w", err)
+		}
+	} else {
+		for _, p := range paths {
+			if _, err := wt.Add(p); err != nil {
+				return CommitResult{}, fmt.Errorf("failed to stage %s: %w", p, err)
+			}
+		}
+	}
+
+	status, err := wt.Status()
+	if err != nil {
+		return CommitResult{}, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	var staged []string
+	for path, fileStatus := range status {
+		if fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked {
+			staged = append(staged, path)
+		}
+	}
+
+	if len(staged) == 0 && !allowEmpty {
+		return CommitResult{}, ErrCleanWorkingTree
+	}
+
+	hash, err := wt.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  author.Name,
+			Email: author.Email,
+			When:  time.Now(),
+		},
+		AllowEmptyCommits: allowEmpty,
+	})
+	if err != nil {
+		return CommitResult{}, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return CommitResult{Hash: hash.String(), Files: staged}, nil
+}
+
+// dryRunStaged reports which files `wt.Add(paths...)` (or AddWithOptions
+// with All, if paths is empty) would stage, by reading the worktree's
+// status against HEAD without writing anything to the index - a dry run
+// must not leave the repo's index staged/dirty as a side effect.
+func dryRunStaged(wt *git.Worktree, paths []string) ([]string, error) {
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+
+	var staged []string
+	for path, fileStatus := range status {
+		if fileStatus.Staging == git.Unmodified && fileStatus.Worktree == git.Unmodified {
+			continue
+		}
+		if pathMatches(path, paths) {
+			staged = append(staged, path)
+		}
+	}
+	return staged, nil
+}
+
+// pathMatches reports whether path is, or is under, one of paths, mirroring
+// what `git add <paths>` would pick up. An empty paths matches everything,
+// mirroring AddOptions{All: true}.
+func pathMatches(path string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package gitops
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func initRepo(t *testing.T) *Repo {
+	t.Helper()
+
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatalf("git.PlainInit: %v", err)
+	}
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return repo
+}
+
+func writeFile(t *testing.T, repo *Repo, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(repo.path, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile(%s): %v", name, err)
+	}
+}
+
+func statusOf(t *testing.T, repo *Repo) git.Status {
+	t.Helper()
+	wt, err := repo.repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree: %v", err)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	return status
+}
+
+func TestCommitEmptyMessage(t *testing.T) {
+	repo := initRepo(t)
+
+	_, err := repo.Commit(context.Background(), nil, "   ", Author{Name: "a", Email: "a@b.c"}, false, false)
+	if err != ErrEmptyMessage {
+		t.Fatalf("err = %v, want ErrEmptyMessage", err)
+	}
+}
+
+func TestCommitCleanWorkingTree(t *testing.T) {
+	repo := initRepo(t)
+
+	_, err := repo.Commit(context.Background(), nil, "nothing to commit", Author{Name: "a", Email: "a@b.c"}, false, false)
+	if err != ErrCleanWorkingTree {
+		t.Fatalf("err = %v, want ErrCleanWorkingTree", err)
+	}
+}
+
+func TestCommitDryRunDoesNotMutateIndex(t *testing.T) {
+	repo := initRepo(t)
+	writeFile(t, repo, "a.txt", "hello")
+	writeFile(t, repo, "b.txt", "world")
+
+	result, err := repo.Commit(context.Background(), nil, "checkpoint", Author{Name: "a", Email: "a@b.c"}, false, true)
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if !result.DryRun {
+		t.Errorf("DryRun = false, want true")
+	}
+	if result.Hash != "" {
+		t.Errorf("Hash = %q, want empty", result.Hash)
+	}
+
+	want := []string{"a.txt", "b.txt"}
+	got := append([]string{}, result.Files...)
+	sort.Strings(got)
+	if !equalStrings(got, want) {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+
+	// A dry run must not stage anything into the index.
+	status := statusOf(t, repo)
+	for _, f := range want {
+		fs, ok := status[f]
+		if !ok {
+			t.Fatalf("status missing entry for %s", f)
+		}
+		if fs.Staging != git.Untracked {
+			t.Errorf("status[%s].Staging = %v, want Untracked (dry run must not stage)", f, fs.Staging)
+		}
+	}
+}
+
+func TestCommitDryRunFiltersByPaths(t *testing.T) {
+	repo := initRepo(t)
+	writeFile(t, repo, "a.txt", "hello")
+	writeFile(t, repo, "b.txt", "world")
+
+	result, err := repo.Commit(context.Background(), []string{"a.txt"}, "checkpoint", Author{Name: "a", Email: "a@b.c"}, false, true)
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if !equalStrings(result.Files, []string{"a.txt"}) {
+		t.Errorf("Files = %v, want [a.txt]", result.Files)
+	}
+}
+
+func TestCommitDryRunCleanWorkingTree(t *testing.T) {
+	repo := initRepo(t)
+
+	_, err := repo.Commit(context.Background(), nil, "checkpoint", Author{Name: "a", Email: "a@b.c"}, false, true)
+	if err != ErrCleanWorkingTree {
+		t.Fatalf("err = %v, want ErrCleanWorkingTree", err)
+	}
+}
+
+func TestCommitReal(t *testing.T) {
+	repo := initRepo(t)
+	writeFile(t, repo, "a.txt", "hello")
+
+	result, err := repo.Commit(context.Background(), nil, "initial commit", Author{Name: "a", Email: "a@b.c"}, false, false)
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if result.DryRun {
+		t.Errorf("DryRun = true, want false")
+	}
+	if result.Hash == "" {
+		t.Errorf("Hash is empty, want a commit hash")
+	}
+	if !equalStrings(result.Files, []string{"a.txt"}) {
+		t.Errorf("Files = %v, want [a.txt]", result.Files)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if head != result.Hash {
+		t.Errorf("HEAD = %s, want %s", head, result.Hash)
+	}
+}
+
+func TestCommitAllowEmpty(t *testing.T) {
+	repo := initRepo(t)
+
+	result, err := repo.Commit(context.Background(), nil, "empty commit", Author{Name: "a", Email: "a@b.c"}, true, false)
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if result.Hash == "" {
+		t.Errorf("Hash is empty, want a commit hash")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,325 @@
+// Package gitops wraps github.com/go-git/go-git/v5 with the small set of
+// typed operations the control plane needs against a project's repository,
+// so handlers don't have to shell out to the git binary and parse its
+// output by hand.
+package gitops
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// Repo wraps an opened repository at a fixed path.
+type Repo struct {
+	repo *git.Repository
+	path string
+}
+
+// Open opens the git repository rooted at path.
+func Open(path string) (*Repo, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository at %s: %w", path, err)
+	}
+	return &Repo{repo: repo, path: path}, nil
+}
+
+// ChangeType describes how a file was affected by a commit.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeModified ChangeType = "modified"
+	ChangeDeleted  ChangeType = "deleted"
+	ChangeRenamed  ChangeType = "renamed"
+)
+
+// CommitFile is a single file touched by a commit. OldPath is only set for
+// ChangeRenamed.
+type CommitFile struct {
+	Path    string
+	OldPath string
+	Type    ChangeType
+}
+
+// Commit is a single entry in a repository's history.
+type Commit struct {
+	Hash    string
+	Message string
+	Date    string
+	Files   []CommitFile
+}
+
+// FileDiff holds a file's content before and after a change. IsBinary is
+// set when either side isn't text; a binary side's text is left empty,
+// while a text side is still populated.
+type FileDiff struct {
+	Path     string
+	OldText  string
+	NewText  string
+	IsBinary bool
+}
+
+// Log returns up to limit commits reachable from HEAD, starting after the
+// commit identified by cursor (or from HEAD if cursor is empty), along with
+// whether more commits remain beyond the returned page.
+func (r *Repo) Log(ctx context.Context, limit int, cursor string) ([]Commit, bool, error) {
+	iter, err := r.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get git log: %w", err)
+	}
+	defer iter.Close()
+
+	commits := make([]Commit, 0, limit)
+	hasNextPage := false
+	skipping := cursor != ""
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		c, err := iter.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to walk git log: %w", err)
+		}
+
+		if skipping {
+			if c.Hash.String() == cursor {
+				skipping = false
+			}
+			continue
+		}
+
+		if len(commits) == limit {
+			hasNextPage = true
+			break
+		}
+
+		files, err := r.commitFiles(ctx, c)
+		if err != nil {
+			return nil, false, err
+		}
+
+		commits = append(commits, Commit{
+			Hash:    c.Hash.String(),
+			Message: c.Message,
+			Date:    c.Author.When.Format("2006-01-02 15:04:05 -0700"),
+			Files:   files,
+		})
+	}
+
+	return commits, hasNextPage, nil
+}
+
+// CommitDiff returns the per-file diffs introduced by the commit identified
+// by hash, relative to its first parent (or the empty tree for a root
+// commit).
+func (r *Repo) CommitDiff(ctx context.Context, hash string) ([]FileDiff, error) {
+	c, err := r.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, fmt.Errorf("commit %s not found: %w", hash, err)
+	}
+
+	changes, err := r.commitChanges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]FileDiff, 0, len(changes))
+	for _, change := range changes {
+		diff, err := fileDiffFromChange(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read diff for %s: %w", changePath(change), err)
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// FileDiff returns the before/after content of a single file at the commit
+// identified by hash, relative to its first parent. Either side is left
+// empty if the file didn't exist there (i.e. it was added or deleted).
+func (r *Repo) FileDiff(ctx context.Context, hash, path string) (FileDiff, error) {
+	if err := ctx.Err(); err != nil {
+		return FileDiff{}, err
+	}
+
+	c, err := r.repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return FileDiff{}, fmt.Errorf("commit %s not found: %w", hash, err)
+	}
+
+	diff := FileDiff{Path: path}
+
+	if content, isBinary, ok := fileContentsAt(c, path); ok {
+		diff.NewText = content
+		diff.IsBinary = diff.IsBinary || isBinary
+	}
+
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return FileDiff{}, fmt.Errorf("failed to get parent of %s: %w", hash, err)
+		}
+		if content, isBinary, ok := fileContentsAt(parent, path); ok {
+			diff.OldText = content
+			diff.IsBinary = diff.IsBinary || isBinary
+		}
+	}
+
+	return diff, nil
+}
+
+// Head returns the hash HEAD currently points to.
+func (r *Repo) Head() (string, error) {
+	head, err := r.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return head.Hash().String(), nil
+}
+
+// Reset moves HEAD and the branch it points to back to hash, applying mode
+// exactly as `git reset --<mode>` would.
+func (r *Repo) Reset(ctx context.Context, hash string, mode ResetMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if err := wt.Reset(&git.ResetOptions{
+		Commit: plumbing.NewHash(hash),
+		Mode:   mode.toGitResetMode(),
+	}); err != nil {
+		return fmt.Errorf("failed to reset to commit %s: %w", hash, err)
+	}
+	return nil
+}
+
+// commitFiles returns the files touched by c relative to its first parent.
+func (r *Repo) commitFiles(ctx context.Context, c *object.Commit) ([]CommitFile, error) {
+	changes, err := r.commitChanges(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]CommitFile, 0, len(changes))
+	for _, change := range changes {
+		cf, err := commitFileFromChange(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify change for %s: %w", changePath(change), err)
+		}
+		files = append(files, cf)
+	}
+	return files, nil
+}
+
+// commitChanges diffs c's tree against its first parent's tree (or nil for
+// a root commit), with rename detection enabled.
+func (r *Repo) commitChanges(ctx context.Context, c *object.Commit) (object.Changes, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree for %s: %w", c.Hash, err)
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get parent of %s: %w", c.Hash, err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, fmt.Errorf("failed to get parent tree for %s: %w", c.Hash, err)
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff trees for %s: %w", c.Hash, err)
+	}
+	return changes, nil
+}
+
+func commitFileFromChange(change *object.Change) (CommitFile, error) {
+	action, err := change.Action()
+	if err != nil {
+		return CommitFile{}, err
+	}
+
+	switch action {
+	case merkletrie.Insert:
+		return CommitFile{Path: change.To.Name, Type: ChangeAdded}, nil
+	case merkletrie.Delete:
+		return CommitFile{Path: change.From.Name, Type: ChangeDeleted}, nil
+	default:
+		if change.From.Name != change.To.Name {
+			return CommitFile{Path: change.To.Name, OldPath: change.From.Name, Type: ChangeRenamed}, nil
+		}
+		return CommitFile{Path: change.To.Name, Type: ChangeModified}, nil
+	}
+}
+
+func fileDiffFromChange(change *object.Change) (FileDiff, error) {
+	from, to, err := change.Files()
+	if err != nil {
+		return FileDiff{}, err
+	}
+
+	diff := FileDiff{Path: changePath(change)}
+
+	if from != nil {
+		if isBinary, _ := from.IsBinary(); isBinary {
+			diff.IsBinary = true
+		} else if content, err := from.Contents(); err == nil {
+			diff.OldText = content
+		}
+	}
+
+	if to != nil {
+		if isBinary, _ := to.IsBinary(); isBinary {
+			diff.IsBinary = true
+		} else if content, err := to.Contents(); err == nil {
+			diff.NewText = content
+		}
+	}
+
+	return diff, nil
+}
+
+func fileContentsAt(c *object.Commit, path string) (content string, isBinary bool, ok bool) {
+	file, err := c.File(path)
+	if err != nil {
+		return "", false, false
+	}
+
+	if isBinary, _ = file.IsBinary(); isBinary {
+		return "", true, true
+	}
+
+	content, err = file.Contents()
+	if err != nil {
+		return "", false, false
+	}
+	return content, false, true
+}
+
+func changePath(change *object.Change) string {
+	if change.To.Name != "" {
+		return change.To.Name
+	}
+	return change.From.Name
+}
@@ -0,0 +1,123 @@
+package gitops
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	transport "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// BasicAuth is the HTTP basic-auth credentials used to authenticate a push
+// or pull against a remote. A zero value means "no authentication".
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) toTransportAuth() transport.AuthMethod {
+	if a.Password == "" {
+		return nil
+	}
+	return &transport.BasicAuth{Username: a.Username, Password: a.Password}
+}
+
+// RemoteInfo describes a configured remote.
+type RemoteInfo struct {
+	Name string
+	URLs []string
+}
+
+// ListRemotes returns the repository's configured remotes.
+func (r *Repo) ListRemotes(ctx context.Context) ([]RemoteInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	remotes, err := r.repo.Remotes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+
+	infos := make([]RemoteInfo, len(remotes))
+	for i, remote := range remotes {
+		cfg := remote.Config()
+		infos[i] = RemoteInfo{Name: cfg.Name, URLs: cfg.URLs}
+	}
+	return infos, nil
+}
+
+// RemoteURL returns the first configured URL for remote name.
+func (r *Repo) RemoteURL(name string) (string, error) {
+	remote, err := r.repo.Remote(name)
+	if err != nil {
+		return "", fmt.Errorf("remote %s not found: %w", name, err)
+	}
+	cfg := remote.Config()
+	if len(cfg.URLs) == 0 {
+		return "", fmt.Errorf("remote %s has no URL configured", name)
+	}
+	return cfg.URLs[0], nil
+}
+
+// AddRemote registers a new remote named name pointing at url.
+func (r *Repo) AddRemote(ctx context.Context, name, url string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := r.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+		return fmt.Errorf("failed to add remote %s: %w", name, err)
+	}
+	return nil
+}
+
+// Push pushes branch (or whatever the remote's configured refspecs cover,
+// if branch is empty) to remoteName, authenticating with auth when set.
+func (r *Repo) Push(ctx context.Context, remoteName, branch string, auth BasicAuth, force bool) error {
+	opts := &git.PushOptions{
+		RemoteName: remoteName,
+		Auth:       auth.toTransportAuth(),
+		Force:      force,
+	}
+	if branch != "" {
+		ref := plumbing.NewBranchReferenceName(branch)
+		opts.RefSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))}
+	}
+
+	if err := r.repo.PushContext(ctx, opts); err != nil {
+		if err == git.NoErrAlreadyUpToDate {
+			return nil
+		}
+		return fmt.Errorf("failed to push to %s: %w", remoteName, err)
+	}
+	return nil
+}
+
+// Pull fetches branch (or HEAD's upstream, if branch is empty) from
+// remoteName and merges it into the working tree.
+func (r *Repo) Pull(ctx context.Context, remoteName, branch string, auth BasicAuth, force bool) error {
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	opts := &git.PullOptions{
+		RemoteName: remoteName,
+		Auth:       auth.toTransportAuth(),
+		Force:      force,
+	}
+	if branch != "" {
+		opts.ReferenceName = plumbing.NewBranchReferenceName(branch)
+	}
+
+	if err := wt.PullContext(ctx, opts); err != nil {
+		if err == git.NoErrAlreadyUpToDate {
+			return nil
+		}
+		return fmt.Errorf("failed to pull from %s: %w", remoteName, err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package gitops
+
+import "github.com/go-git/go-git/v5"
+
+// ResetMode mirrors git's --hard/--mixed/--soft reset flags.
+type ResetMode string
+
+const (
+	// ResetHard resets the index and working tree, discarding all
+	// uncommitted changes.
+	ResetHard ResetMode = "hard"
+	// ResetMixed resets the index but leaves the working tree untouched.
+	ResetMixed ResetMode = "mixed"
+	// ResetSoft leaves both the index and working tree untouched, only
+	// moving HEAD and the branch pointer.
+	ResetSoft ResetMode = "soft"
+)
+
+func (m ResetMode) toGitResetMode() git.ResetMode {
+	switch m {
+	case ResetSoft:
+		return git.SoftReset
+	case ResetMixed:
+		return git.MixedReset
+	default:
+		return git.HardReset
+	}
+}
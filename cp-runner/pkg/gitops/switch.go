@@ -0,0 +1,80 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// SwitchMode selects how SwitchCommit moves the working tree to a target
+// commit: the three git reset flavors, which move the current branch
+// pointer, plus SwitchCheckout, which detaches HEAD at the commit instead
+// of moving any branch.
+type SwitchMode string
+
+const (
+	SwitchHard     SwitchMode = "hard"
+	SwitchMixed    SwitchMode = "mixed"
+	SwitchSoft     SwitchMode = "soft"
+	SwitchCheckout SwitchMode = "checkout"
+)
+
+// ErrDirtyWorkingTree is returned by SwitchCommit when mode is SwitchHard
+// and the working tree has uncommitted changes, and force is false.
+var ErrDirtyWorkingTree = errors.New("working tree has uncommitted changes")
+
+// SwitchCommit moves the repository to hash using mode. If createBranch is
+// non-empty, a new branch by that name is created pointing at hash and
+// checked out, instead of moving the current branch's pointer. A hard
+// reset against a dirty working tree is refused unless force is true.
+func (r *Repo) SwitchCommit(ctx context.Context, hash string, mode SwitchMode, createBranch string, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
+
+	if mode == SwitchHard && !force {
+		status, err := wt.Status()
+		if err != nil {
+			return fmt.Errorf("failed to get worktree status: %w", err)
+		}
+		if !status.IsClean() {
+			return ErrDirtyWorkingTree
+		}
+	}
+
+	if createBranch != "" {
+		if err := r.createBranchAt(createBranch, hash); err != nil {
+			return err
+		}
+		if err := wt.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(createBranch),
+			Force:  force,
+		}); err != nil {
+			return fmt.Errorf("failed to checkout branch %s: %w", createBranch, err)
+		}
+		return nil
+	}
+
+	if mode == SwitchCheckout {
+		if err := wt.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(hash), Force: force}); err != nil {
+			return fmt.Errorf("failed to checkout commit %s: %w", hash, err)
+		}
+		return nil
+	}
+
+	if err := wt.Reset(&git.ResetOptions{
+		Commit: plumbing.NewHash(hash),
+		Mode:   ResetMode(mode).toGitResetMode(),
+	}); err != nil {
+		return fmt.Errorf("failed to reset to commit %s: %w", hash, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package hooks runs a project's declared hook scripts after the control
+// plane mutates its working tree (switching commits, committing, adding a
+// package), the way Gitea runs server-side git hooks on its own merges and
+// edits. This lets a generated project declare follow-up actions (rerun
+// codegen after a revert, regenerate types after a dependency change)
+// without the control plane hard-coding any framework knowledge.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Standard and control-plane-specific hook names. post-checkout,
+// post-merge, and post-commit mirror git's own hooks; post-switch is a
+// control plane addition fired by the commit-switch endpoint, which
+// doesn't correspond to exactly one native git hook since it can reset,
+// soft-move, or check out.
+const (
+	PostCheckout = "post-checkout"
+	PostMerge    = "post-merge"
+	PostCommit   = "post-commit"
+	PostSwitch   = "post-switch"
+)
+
+// dirs are the directories searched for a hook script, relative to a
+// project's root, in the order they're run. .codepanda/hooks exists
+// alongside .git/hooks so a hook can be committed to the project (unlike
+// .git/hooks, which git never tracks).
+var dirs = []string{".git/hooks", ".codepanda/hooks"}
+
+// runTimeout bounds how long a single hook script may run before it's
+// killed.
+const runTimeout = 30 * time.Second
+
+// Result is the outcome of running a single hook script.
+type Result struct {
+	Name     string
+	ExitCode int
+	Output   string
+}
+
+// Run executes the executable script named name (e.g. "post-checkout") in
+// each of $projectPath/.git/hooks and $projectPath/.codepanda/hooks that
+// has one, passing args as that hook's standard arguments. Directories and
+// scripts that don't exist, or exist but aren't executable, are silently
+// skipped, matching git's own hook behavior.
+func Run(ctx context.Context, projectPath, name string, args ...string) []Result {
+	var results []Result
+	for _, dir := range dirs {
+		path := filepath.Join(projectPath, dir, name)
+
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+			continue
+		}
+
+		results = append(results, runHook(ctx, projectPath, filepath.Join(dir, name), path, args))
+	}
+	return results
+}
+
+func runHook(ctx context.Context, projectPath, displayName, path string, args []string) Result {
+	hookCtx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(hookCtx, path, args...)
+	cmd.Dir = projectPath
+
+	output, err := cmd.CombinedOutput()
+
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+	}
+
+	return Result{Name: displayName, ExitCode: exitCode, Output: string(output)}
+}
@@ -0,0 +1,118 @@
+// Package listenfd implements the systemd LISTEN_FDS socket activation
+// protocol, letting a server pick up a listener that was already bound by a
+// supervisor (systemd itself, or the systemfd CLI in development) instead of
+// binding its own. That's what makes a zero-downtime restart of the binary
+// possible: the listening socket outlives any single process, so requests
+// that arrive while the new process is starting just queue on the kernel
+// backlog instead of being refused.
+package listenfd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFDsStart is the first file descriptor systemd hands off - fds 0-2
+// are stdio, so passed sockets start at 3.
+const listenFDsStart = 3
+
+var (
+	once      sync.Once
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+	parseErr  error
+)
+
+// Take returns the pre-bound listener passed under name (systemd's
+// LISTEN_FDNAMES, or the file descriptor's 0-based index if the launcher
+// didn't set one), removing it from the pool. ok is false if this process
+// wasn't socket-activated, or no fd was passed under that name - either way
+// the caller should fall back to net.Listen. Once a name has been taken,
+// later calls for it also report ok=false, since the fd belongs to whichever
+// server already took it; a process normally takes each name once, at
+// startup. Safe to call concurrently for different names - e.g. the control
+// plane taking "control" while a project proxy takes "proxy".
+func Take(name string) (net.Listener, bool, error) {
+	once.Do(func() { listeners, parseErr = collect() })
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if parseErr != nil {
+		return nil, false, parseErr
+	}
+	l, ok := listeners[name]
+	if ok {
+		delete(listeners, name)
+	}
+	return l, ok, nil
+}
+
+// collect reads every file descriptor systemd passed, per the LISTEN_FDS
+// protocol, keyed by LISTEN_FDNAMES.
+func collect() (map[string]net.Listener, error) {
+	n, err := fdCount()
+	if err != nil || n == 0 {
+		return map[string]net.Listener{}, err
+	}
+
+	names := fdNames(n)
+	out := make(map[string]net.Listener, n)
+	for i := 0; i < n; i++ {
+		fd := listenFDsStart + i
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("listenfd-%s", names[i]))
+		l, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("listenfd: fd %d (%s): %w", fd, names[i], err)
+		}
+		out[names[i]] = l
+	}
+	return out, nil
+}
+
+// fdCount returns how many file descriptors were passed to this process,
+// validating LISTEN_PID names us specifically. Zero (with no error) means
+// this process wasn't socket-activated, which is the common case.
+func fdCount() (int, error) {
+	pidStr := os.Getenv("LISTEN_PID")
+	fdsStr := os.Getenv("LISTEN_FDS")
+	if pidStr == "" || fdsStr == "" {
+		return 0, nil
+	}
+
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return 0, fmt.Errorf("listenfd: invalid LISTEN_PID %q: %w", pidStr, err)
+	}
+	if pid != os.Getpid() {
+		// Meant for a different process in the launch chain - nothing for us.
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(fdsStr)
+	if err != nil {
+		return 0, fmt.Errorf("listenfd: invalid LISTEN_FDS %q: %w", fdsStr, err)
+	}
+	return n, nil
+}
+
+// fdNames returns the name for each of the n passed file descriptors, from
+// LISTEN_FDNAMES (colon-separated, per systemd) if set, or its 0-based index
+// otherwise.
+func fdNames(n int) []string {
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	out := make([]string, n)
+	for i := range out {
+		if i < len(names) && names[i] != "" {
+			out[i] = names[i]
+		} else {
+			out[i] = strconv.Itoa(i)
+		}
+	}
+	return out
+}
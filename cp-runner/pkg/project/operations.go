@@ -0,0 +1,100 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Operation describes a long-running, cancellable operation in flight for a
+// project (lint, build check, dependency install, etc).
+type Operation struct {
+	ID        string
+	Kind      string
+	StartedAt time.Time
+}
+
+// trackedOperation pairs an Operation with the cancel func that aborts it.
+type trackedOperation struct {
+	Operation
+	cancel context.CancelFunc
+}
+
+// opGate serializes operations of the same kind for a project so, e.g., two
+// concurrent AddPackage calls don't race on the lockfile, while operations
+// of different kinds (lint vs. dependencies) can still run concurrently.
+type opGate struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newOpGate() *opGate {
+	return &opGate{locks: make(map[string]*sync.Mutex)}
+}
+
+func (g *opGate) lockFor(kind string) *sync.Mutex {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	l, ok := g.locks[kind]
+	if !ok {
+		l = &sync.Mutex{}
+		g.locks[kind] = l
+	}
+	return l
+}
+
+// beginOperation serializes against any other in-flight operation of the
+// same kind, registers the operation so it shows up in ListOperations, and
+// derives a context from ctx that CancelOperation can cancel. The returned
+// end func must be called exactly once when the operation completes.
+func (o *Orchestrator) beginOperation(ctx context.Context, kind string) (context.Context, func()) {
+	gate := o.opGate.lockFor(kind)
+	gate.Lock()
+
+	opCtx, cancel := context.WithCancel(ctx)
+	id := fmt.Sprintf("%s-%d", kind, o.opSeq.Add(1))
+
+	o.opsMu.Lock()
+	o.ops[id] = &trackedOperation{
+		Operation: Operation{ID: id, Kind: kind, StartedAt: time.Now()},
+		cancel:    cancel,
+	}
+	o.opsMu.Unlock()
+
+	return opCtx, func() {
+		cancel()
+		o.opsMu.Lock()
+		delete(o.ops, id)
+		o.opsMu.Unlock()
+		gate.Unlock()
+	}
+}
+
+// ListOperations returns a snapshot of the operations currently in flight
+// for this project.
+func (o *Orchestrator) ListOperations() []Operation {
+	o.opsMu.Lock()
+	defer o.opsMu.Unlock()
+
+	out := make([]Operation, 0, len(o.ops))
+	for _, op := range o.ops {
+		out = append(out, op.Operation)
+	}
+	return out
+}
+
+// CancelOperation cancels the in-flight operation with the given ID,
+// reporting whether it was found.
+func (o *Orchestrator) CancelOperation(id string) bool {
+	o.opsMu.Lock()
+	op, ok := o.ops[id]
+	o.opsMu.Unlock()
+
+	if !ok {
+		return false
+	}
+	op.cancel()
+	return true
+}
@@ -9,10 +9,12 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jjleng/cp-runner/pkg/proxy"
 	"github.com/jjleng/cp-runner/pkg/runtime"
+	"github.com/jjleng/cp-runner/pkg/runtime/diag"
 )
 
 const startupTimeout = 60 * time.Second
@@ -24,16 +26,130 @@ type Orchestrator struct {
 	isRestarting bool
 	mu           sync.Mutex
 	appPort      int
+
+	// opGate serializes concurrent operations of the same kind (e.g. two
+	// AddPackage calls) so they don't race on the lockfile, and ops/opsMu
+	// track what's currently in flight for ListOperations/CancelOperation.
+	opGate *opGate
+	opsMu  sync.Mutex
+	ops    map[string]*trackedOperation
+	opSeq  atomic.Uint64
+
+	// liveReload and watcher drive the preview's live-reload websocket; see
+	// SetLiveReload and watcher.go. watcher is created lazily, on the first
+	// restart after live reload is enabled.
+	liveReload bool
+	watcher    *Watcher
 }
 
 func NewOrchestrator(projectPath string, pkgManager runtime.PackageManager) *Orchestrator {
-	return &Orchestrator{
+	o := &Orchestrator{
 		projectPath:  projectPath,
 		supervisor:   runtime.NewSupervisor(projectPath, pkgManager),
 		isRestarting: false,
+		opGate:       newOpGate(),
+		ops:          make(map[string]*trackedOperation),
+	}
+	o.supervisor.OnStateChange(o.handleSupervisorState)
+	return o
+}
+
+// handleSupervisorState reacts to the dev server's supervised lifecycle:
+// while it's in Backoff or Fatal, the proxy is put back into loading mode
+// so users see a "restarting" page instead of a connection-refused error,
+// and once it's Running again the proxy resumes once the app is actually
+// reachable.
+func (o *Orchestrator) handleSupervisorState(state runtime.ProcessState) {
+	o.mu.Lock()
+	proxyServer := o.proxyServer
+	o.mu.Unlock()
+
+	if proxyServer == nil {
+		return
+	}
+
+	switch state {
+	case runtime.StateBackoff, runtime.StateFatal:
+		proxyServer.SetLoadingMode(true)
+	case runtime.StateRunning:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+			defer cancel()
+			if err := o.waitForAppReady(ctx); err == nil {
+				o.markAppReady(proxyServer)
+			}
+		}()
+	}
+}
+
+// markAppReady switches proxyServer out of loading mode and, if live
+// reload is enabled, tells connected clients to reload so a tab left open
+// through a restart doesn't have to be refreshed by hand.
+func (o *Orchestrator) markAppReady(proxyServer *proxy.ProxyServer) {
+	proxyServer.SetLoadingMode(false)
+	if o.liveReload {
+		proxyServer.PublishReload(proxy.ReloadEvent{Type: "reload"})
+	}
+}
+
+// SetLiveReload enables or disables live-reload for this project. It must
+// be called before RestartProject to take effect on the next (re)start.
+func (o *Orchestrator) SetLiveReload(enabled bool) {
+	o.mu.Lock()
+	o.liveReload = enabled
+	o.mu.Unlock()
+}
+
+// ensureWatcher starts the live-reload filesystem watcher the first time
+// it's needed and fans its events out to the current proxy server.
+func (o *Orchestrator) ensureWatcher() error {
+	o.mu.Lock()
+	if o.watcher != nil {
+		o.mu.Unlock()
+		return nil
+	}
+	w := NewWatcher(o.projectPath)
+	o.watcher = w
+	o.mu.Unlock()
+
+	if err := w.Start(); err != nil {
+		return err
+	}
+
+	go o.pumpReloadEvents(w)
+	return nil
+}
+
+// pumpReloadEvents forwards w's reload events to whichever proxy server is
+// current at the time each event arrives, so it keeps working across
+// restarts that swap in a new *proxy.ProxyServer.
+func (o *Orchestrator) pumpReloadEvents(w *Watcher) {
+	events, unsubscribe := w.Subscribe()
+	defer unsubscribe()
+
+	for event := range events {
+		o.mu.Lock()
+		proxyServer := o.proxyServer
+		o.mu.Unlock()
+
+		if proxyServer != nil {
+			proxyServer.PublishReload(event)
+		}
 	}
 }
 
+// State returns the dev server's current supervision state (stopped,
+// starting, running, backoff, or fatal).
+func (o *Orchestrator) State() runtime.ProcessState {
+	return o.supervisor.State()
+}
+
+// LastFailure returns the reason the dev server most recently went Fatal,
+// or "" if it never has.
+func (o *Orchestrator) LastFailure() string {
+	return o.supervisor.LastFailure()
+}
+
 func (o *Orchestrator) setRestarting(value bool) bool {
 	o.mu.Lock()
 	defer o.mu.Unlock()
@@ -46,13 +162,13 @@ func (o *Orchestrator) setRestarting(value bool) bool {
 	return true
 }
 
-func (o *Orchestrator) RestartProject(port int, appPort int) error {
+func (o *Orchestrator) RestartProject(ctx context.Context, port int, appPort int) error {
 	// Try to set restarting state to true
 	if !o.setRestarting(true) {
 		// If already restarting, just wait for it to complete
-		ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+		waitCtx, cancel := context.WithTimeout(ctx, startupTimeout)
 		defer cancel()
-		return o.waitForAppReady(ctx)
+		return o.waitForAppReady(waitCtx)
 	}
 
 	// Ensure we reset the restarting state when done
@@ -67,10 +183,16 @@ func (o *Orchestrator) RestartProject(port int, appPort int) error {
 	// Check if node_modules exists
 	nodeModulesPath := filepath.Join(o.projectPath, "node_modules")
 	if _, err := os.Stat(nodeModulesPath); os.IsNotExist(err) {
-		// Only install dependencies if node_modules doesn't exist
-		installCmd := exec.Command("sh", "-c", o.supervisor.GetPackageManager().GetInstallCmd())
+		// Only install dependencies if node_modules doesn't exist. This
+		// shares the "dependencies" gate with AddPackage so the two don't
+		// race on the lockfile.
+		opCtx, end := o.beginOperation(ctx, "dependencies")
+		name, args := o.supervisor.GetPackageManager().InstallCmd()
+		installCmd := exec.CommandContext(opCtx, name, args...)
 		installCmd.Dir = o.projectPath
-		if output, err := installCmd.CombinedOutput(); err != nil {
+		output, err := installCmd.CombinedOutput()
+		end()
+		if err != nil {
 			return fmt.Errorf("failed to install dependencies: %v\nOutput: %s", err, output)
 		}
 	}
@@ -78,6 +200,17 @@ func (o *Orchestrator) RestartProject(port int, appPort int) error {
 	// Set up and start the proxy server
 	proxyServer := proxy.NewProxyServer(port, appPort)
 	proxyServer.SetLoadingMode(true)
+
+	o.mu.Lock()
+	liveReload := o.liveReload
+	o.mu.Unlock()
+	if liveReload {
+		proxyServer.SetLiveReload(true)
+		if err := o.ensureWatcher(); err != nil {
+			log.Printf("Warning: failed to start live-reload watcher: %v", err)
+		}
+	}
+
 	o.proxyServer = proxyServer
 
 	// Start the proxy in a goroutine
@@ -94,18 +227,18 @@ func (o *Orchestrator) RestartProject(port int, appPort int) error {
 	}
 
 	// Create a context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	readyCtx, cancel := context.WithTimeout(ctx, startupTimeout)
 	defer cancel()
 
 	// Wait for the app to be ready
-	if err := o.waitForAppReady(ctx); err != nil {
+	if err := o.waitForAppReady(readyCtx); err != nil {
 		proxyServer.Stop()
 		o.supervisor.StopCurrentProcess()
 		return fmt.Errorf("app failed to start: %w", err)
 	}
 
 	// Switch to app mode
-	proxyServer.SetLoadingMode(false)
+	o.markAppReady(proxyServer)
 
 	return nil
 }
@@ -209,12 +342,45 @@ func (o *Orchestrator) Cleanup() {
 	}
 }
 
-func (o *Orchestrator) RunLint() (bool, string) {
-	return o.supervisor.RunLint()
+// ForceKill immediately SIGKILLs the dev server's process group without
+// waiting for a graceful exit. Deliberately doesn't take o.mu: it's called
+// to escalate out of a StopProject/Cleanup call that's already holding it
+// and hanging, so blocking on the same lock would defeat the point.
+func (o *Orchestrator) ForceKill() {
+	o.supervisor.KillProcessGroup()
+}
+
+// RunLint runs the project's lint script and returns only the final
+// buffered result. Kept for backward compatibility; RunLintStream streams
+// incremental output instead.
+func (o *Orchestrator) RunLint(ctx context.Context) (bool, string) {
+	opCtx, end := o.beginOperation(ctx, "lint")
+	defer end()
+	return o.supervisor.RunLint(opCtx)
 }
 
-func (o *Orchestrator) CheckBuildErrors() (bool, string) {
-	return o.supervisor.CheckBuildErrors()
+// CheckBuildErrors runs the project's type check and returns only the
+// final buffered result. Kept for backward compatibility;
+// CheckBuildErrorsStream streams incremental output instead.
+func (o *Orchestrator) CheckBuildErrors(ctx context.Context) (bool, string) {
+	opCtx, end := o.beginOperation(ctx, "check-build")
+	defer end()
+	return o.supervisor.CheckBuildErrors(opCtx)
+}
+
+// RunLintStream runs the project's lint script, or attaches to one already
+// in flight, streaming stdout/stderr lines and a final exit event to every
+// subscriber. Unlike RunLint, it doesn't serialize behind the "lint"
+// operation gate, since concurrent callers are meant to tail the same run
+// rather than wait for it.
+func (o *Orchestrator) RunLintStream(ctx context.Context) (<-chan runtime.StreamEvent, func()) {
+	return o.supervisor.RunLintStream(ctx)
+}
+
+// CheckBuildErrorsStream runs the project's type check, or attaches to one
+// already in flight, streaming output the same way RunLintStream does.
+func (o *Orchestrator) CheckBuildErrorsStream(ctx context.Context) (<-chan runtime.StreamEvent, func()) {
+	return o.supervisor.CheckBuildErrorsStream(ctx)
 }
 
 func (o *Orchestrator) IsRestarting() bool {
@@ -233,8 +399,47 @@ func (o *Orchestrator) GetProjectPath() string {
 	return o.projectPath
 }
 
-func (o *Orchestrator) AddPackage(packageName string) (bool, string) {
-	return o.supervisor.AddPackage(packageName)
+// AddPackage installs packageName and returns only the final buffered
+// result. Kept for backward compatibility; AddPackageStream streams
+// incremental output instead.
+func (o *Orchestrator) AddPackage(ctx context.Context, packageName string, dev bool) (bool, string) {
+	// Shares the "dependencies" gate with RestartProject's install step so
+	// the two don't race on the lockfile.
+	opCtx, end := o.beginOperation(ctx, "dependencies")
+	defer end()
+	return o.supervisor.AddPackage(opCtx, packageName, dev)
+}
+
+// AddPackageStream installs packageName, or attaches to an install already
+// in flight, streaming output the same way RunLintStream does. Shares the
+// "dependencies" gate with AddPackage and RestartProject's install step,
+// held for the life of the underlying install rather than of this call, so
+// a second caller attaching to the same in-flight stream isn't made to
+// wait for it.
+func (o *Orchestrator) AddPackageStream(ctx context.Context, packageName string, dev bool) (<-chan runtime.StreamEvent, func()) {
+	onStart := func() func() {
+		_, end := o.beginOperation(ctx, "dependencies")
+		return end
+	}
+	return o.supervisor.AddPackageStream(ctx, packageName, dev, onStart)
+}
+
+// SubscribeLogs returns a channel carrying the backlog of recent dev-server
+// output followed by live lines, and an unsubscribe func.
+func (o *Orchestrator) SubscribeLogs() (<-chan runtime.LogLine, func()) {
+	return o.supervisor.Subscribe()
+}
+
+// SubscribeLogsAfter is like SubscribeLogs, but only replays backlog lines
+// with a sequence number greater than after.
+func (o *Orchestrator) SubscribeLogsAfter(after uint64) (<-chan runtime.LogLine, func()) {
+	return o.supervisor.SubscribeAfter(after)
+}
+
+// LatestDiagnostics returns the structured diagnostics parsed from dev
+// server output since it was last (re)started.
+func (o *Orchestrator) LatestDiagnostics() []diag.Diagnostic {
+	return o.supervisor.LatestDiagnostics()
 }
 
 // IsRunning checks if the project server is running and healthy
@@ -247,6 +452,11 @@ func (o *Orchestrator) IsRunning() bool {
 	appPort := o.appPort
 	o.mu.Unlock()
 
+	switch o.supervisor.State() {
+	case runtime.StateBackoff, runtime.StateFatal:
+		return false
+	}
+
 	// Create a context with a short timeout for the health check
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
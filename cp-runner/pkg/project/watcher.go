@@ -0,0 +1,177 @@
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jjleng/cp-runner/pkg/filesystem"
+	"github.com/jjleng/cp-runner/pkg/proxy"
+)
+
+// watchDebounce is how long Watcher waits after the last filesystem event
+// before deciding what to broadcast, so a save that touches several files
+// (or a build tool's own churn) collapses into one notification.
+const watchDebounce = 100 * time.Millisecond
+
+// Watcher watches a project's tree for changes and fans out debounced
+// proxy.ReloadEvents to every subscriber, driving the preview's live-reload
+// websocket.
+type Watcher struct {
+	projectPath string
+	fsWatcher   *fsnotify.Watcher
+	done        chan struct{}
+
+	subsMu sync.Mutex
+	subs   map[chan proxy.ReloadEvent]struct{}
+
+	pendingMu sync.Mutex
+	pending   map[string]struct{}
+	timer     *time.Timer
+}
+
+// NewWatcher creates a Watcher over projectPath. Call Start to begin
+// watching and Close to stop.
+func NewWatcher(projectPath string) *Watcher {
+	return &Watcher{
+		projectPath: projectPath,
+		done:        make(chan struct{}),
+		subs:        make(map[chan proxy.ReloadEvent]struct{}),
+		pending:     make(map[string]struct{}),
+	}
+}
+
+// Start begins watching projectPath's tree, skipping the same directories
+// filesystem.BuildFileTree does (node_modules, .git, build output, etc).
+func (w *Watcher) Start() error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	w.fsWatcher = fsWatcher
+
+	if err := w.addTree(w.projectPath); err != nil {
+		fsWatcher.Close()
+		return err
+	}
+
+	go w.run()
+	return nil
+}
+
+// addTree registers every directory under root with the underlying
+// fsnotify watcher, skipping directories filesystem.SkipDirectory excludes.
+func (w *Watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip entries we can't stat/read
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && filesystem.SkipDirectory(d.Name()) {
+			return filepath.SkipDir
+		}
+		if err := w.fsWatcher.Add(path); err != nil {
+			log.Printf("live-reload: failed to watch %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) run() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			w.scheduleFlush(event.Name)
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("live-reload: watcher error: %v", err)
+		}
+	}
+}
+
+// scheduleFlush records path as changed and arms a debounce timer, if one
+// isn't already pending, to decide what to broadcast.
+func (w *Watcher) scheduleFlush(path string) {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	w.pending[path] = struct{}{}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(watchDebounce, w.flush)
+	}
+}
+
+// flush decides, from the batch of paths that changed since the last
+// flush, whether to hot-swap a single stylesheet or fall back to a full
+// page reload, and broadcasts the result.
+func (w *Watcher) flush() {
+	w.pendingMu.Lock()
+	pending := w.pending
+	w.pending = make(map[string]struct{})
+	w.timer = nil
+	w.pendingMu.Unlock()
+
+	if len(pending) == 1 {
+		for path := range pending {
+			if strings.HasSuffix(path, ".css") {
+				rel, err := filepath.Rel(w.projectPath, path)
+				if err != nil {
+					rel = path
+				}
+				w.broadcast(proxy.ReloadEvent{Type: "css", Path: rel})
+				return
+			}
+		}
+	}
+	w.broadcast(proxy.ReloadEvent{Type: "reload"})
+}
+
+func (w *Watcher) broadcast(event proxy.ReloadEvent) {
+	w.subsMu.Lock()
+	defer w.subsMu.Unlock()
+	for ch := range w.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel that receives reload events and an
+// unsubscribe func that must be called once the caller is done reading.
+func (w *Watcher) Subscribe() (<-chan proxy.ReloadEvent, func()) {
+	ch := make(chan proxy.ReloadEvent, 4)
+
+	w.subsMu.Lock()
+	w.subs[ch] = struct{}{}
+	w.subsMu.Unlock()
+
+	return ch, func() {
+		w.subsMu.Lock()
+		delete(w.subs, ch)
+		w.subsMu.Unlock()
+	}
+}
+
+// Close stops watching and releases the underlying fsnotify watcher.
+func (w *Watcher) Close() error {
+	close(w.done)
+	if w.fsWatcher != nil {
+		return w.fsWatcher.Close()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// reloadWSPath is the path the injected live-reload client script connects
+// to. It's handled directly by the proxy rather than forwarded upstream, so
+// it keeps working even while the dev server itself is restarting.
+const reloadWSPath = "/__cp_reload"
+
+// ReloadEvent is a live-reload notification broadcast over the
+// reloadWSPath websocket. Type "reload" tells the client to reload the
+// whole page; "css" tells it to hot-swap the stylesheet named by Path
+// (relative to the project root) instead.
+type ReloadEvent struct {
+	Type string `json:"type"`
+	Path string `json:"path,omitempty"`
+}
+
+// reloadHub tracks the live-reload websocket clients currently connected
+// and broadcasts published events to all of them.
+type reloadHub struct {
+	mu    sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}
+
+func newReloadHub() *reloadHub {
+	return &reloadHub{conns: make(map[*websocket.Conn]struct{})}
+}
+
+func (h *reloadHub) add(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[conn] = struct{}{}
+}
+
+func (h *reloadHub) remove(conn *websocket.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, conn)
+}
+
+func (h *reloadHub) broadcast(event ReloadEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for conn := range h.conns {
+		if err := conn.WriteJSON(event); err != nil {
+			log.Printf("live-reload: failed to notify client: %v", err)
+		}
+	}
+}
+
+var reloadUpgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+// handleReloadWS upgrades a live-reload client connection and keeps it
+// registered until it disconnects. The client never sends anything
+// meaningful; reads are only drained to notice a close.
+func (p *ProxyServer) handleReloadWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := reloadUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("live-reload: failed to upgrade websocket: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	p.reloadHub.add(conn)
+	defer p.reloadHub.remove(conn)
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// SetLiveReload enables or disables live-reload for this proxy: while
+// enabled, HTML responses get the live-reload client script injected and
+// reloadWSPath is served directly instead of being proxied upstream.
+func (p *ProxyServer) SetLiveReload(enabled bool) {
+	p.liveReload.Store(enabled)
+}
+
+// PublishReload broadcasts event to every connected live-reload client. It's
+// a no-op if live-reload isn't enabled or no clients are connected.
+func (p *ProxyServer) PublishReload(event ReloadEvent) {
+	p.reloadHub.broadcast(event)
+}
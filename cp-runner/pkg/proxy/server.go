@@ -2,26 +2,39 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httputil"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/jjleng/cp-runner/pkg/listenfd"
 	html "github.com/jjleng/cp-runner/pkg/utils/html"
 )
 
+// badGatewayBackoff is the delay schedule between retries of a failed
+// dial/connection to the dev server, mirroring the retry behavior of
+// workhorse's badgateway roundtripper during unicorn/puma restarts.
+var badGatewayBackoff = []time.Duration{100 * time.Millisecond, 400 * time.Millisecond, 1 * time.Second}
+
 type ProxyServer struct {
-	server    *http.Server
-	appPort   int
-	isLoading atomic.Bool
-	cache     *staticFileCache
+	server       *http.Server
+	reverseProxy *httputil.ReverseProxy
+	appPort      int
+	isLoading    atomic.Bool
+	cache        *staticFileCache
+	wsConns      *wsConnSet
+	liveReload   atomic.Bool
+	reloadHub    *reloadHub
 }
 
 // staticFileCache provides thread-safe caching of static files
@@ -51,9 +64,12 @@ func (c *staticFileCache) set(filename string, content []byte) {
 
 func NewProxyServer(port int, targetPort int) *ProxyServer {
 	ps := &ProxyServer{
-		appPort: targetPort,
-		cache:   newStaticFileCache(),
+		appPort:   targetPort,
+		cache:     newStaticFileCache(),
+		wsConns:   newWSConnSet(),
+		reloadHub: newReloadHub(),
 	}
+	ps.reverseProxy = ps.newReverseProxy()
 	ps.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", port),
 		Handler: http.HandlerFunc(ps.proxyHandler),
@@ -64,8 +80,24 @@ func NewProxyServer(port int, targetPort int) *ProxyServer {
 	return ps
 }
 
+// newReverseProxy builds the httputil.ReverseProxy used to forward requests
+// to the dev server, wiring in the retrying badGatewayTransport, the HTML
+// injection step, and the bad-gateway fallback.
+func (p *ProxyServer) newReverseProxy() *httputil.ReverseProxy {
+	rp := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = fmt.Sprintf("localhost:%d", p.appPort)
+		},
+		Transport:      newBadGatewayTransport(http.DefaultTransport),
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.handleProxyError,
+	}
+	return rp
+}
+
 func (p *ProxyServer) preloadStaticFiles() {
-	files := []string{"loading.html", "heartbeat.js", "navigation.js"}
+	files := []string{"loading.html", "heartbeat.js", "navigation.js", "bad_gateway.html"}
 	for _, filename := range files {
 		content, err := p.readStaticFile(filename)
 		if err != nil {
@@ -76,7 +108,17 @@ func (p *ProxyServer) preloadStaticFiles() {
 	}
 }
 
+// Start serves the proxy, either on a listener systemd (or systemfd in
+// development) already bound for us under the name "proxy", or by binding
+// one ourselves on p.server.Addr otherwise.
 func (p *ProxyServer) Start() error {
+	l, ok, err := listenfd.Take("proxy")
+	if err != nil {
+		return fmt.Errorf("listenfd: %w", err)
+	}
+	if ok {
+		return p.server.Serve(l)
+	}
 	return p.server.ListenAndServe()
 }
 
@@ -95,6 +137,12 @@ func (p *ProxyServer) Stop() error {
 
 func (p *ProxyServer) SetLoadingMode(loading bool) {
 	p.isLoading.Store(loading)
+	if loading {
+		// Kick active WebSocket clients (e.g. Vite HMR) so they reconnect
+		// against the loading page instead of hanging on a dev server
+		// that's about to go away.
+		p.wsConns.closeAll()
+	}
 }
 
 // readStaticFile reads a file from the static directory with caching
@@ -142,18 +190,15 @@ func (p *ProxyServer) proxyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if p.isLoading.Load() {
-		// Check if request is for HTML or a static asset
-		acceptHeader := strings.ToLower(r.Header.Get("Accept"))
-		path := r.URL.Path
-
-		// Treat requests with Accept header containing "text/html" or requests with
-		// no file extension or ending with "/" as requests for HTML content
-		wantsHTML := strings.Contains(acceptHeader, "text/html") ||
-			!strings.Contains(path[strings.LastIndex(path, "/")+1:], ".") ||
-			strings.HasSuffix(path, "/")
+	// The live-reload client stays connected through dev server restarts,
+	// so it's served before the loading-mode check rather than proxied.
+	if p.liveReload.Load() && r.URL.Path == reloadWSPath {
+		p.handleReloadWS(w, r)
+		return
+	}
 
-		if wantsHTML {
+	if p.isLoading.Load() {
+		if wantsHTML(r) {
 			// Serve loading.html for HTML requests
 			loadingHTML, err := p.readStaticFile("loading.html")
 			if err != nil {
@@ -178,7 +223,7 @@ func (p *ProxyServer) proxyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p.proxyHTTPRequest(w, r)
+	p.reverseProxy.ServeHTTP(w, r)
 }
 
 func isWebSocketUpgrade(r *http.Request) bool {
@@ -186,142 +231,157 @@ func isWebSocketUpgrade(r *http.Request) bool {
 		strings.ToLower(r.Header.Get("Connection")) == "upgrade"
 }
 
-func (p *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	targetConn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", p.appPort))
-	if err != nil {
-		http.Error(w, "Could not connect to app server", http.StatusServiceUnavailable)
-		return
-	}
-	defer targetConn.Close()
+// wantsHTML reports whether the request should be answered with an HTML
+// page (as opposed to a 502/503 for, say, a JS or CSS asset request).
+func wantsHTML(r *http.Request) bool {
+	acceptHeader := strings.ToLower(r.Header.Get("Accept"))
+	path := r.URL.Path
 
-	err = r.Write(targetConn)
-	if err != nil {
-		http.Error(w, "Failed to proxy WebSocket request", http.StatusInternalServerError)
-		return
-	}
+	return strings.Contains(acceptHeader, "text/html") ||
+		!strings.Contains(path[strings.LastIndex(path, "/")+1:], ".") ||
+		strings.HasSuffix(path, "/")
+}
 
-	hj, ok := w.(http.Hijacker)
-	if !ok {
-		http.Error(w, "WebSocket proxy not supported", http.StatusInternalServerError)
-		return
-	}
-	clientConn, _, err := hj.Hijack()
-	if err != nil {
-		http.Error(w, "Failed to hijack connection", http.StatusInternalServerError)
-		return
+// modifyResponse strips caching headers (replacing them with no-cache
+// equivalents, as the dev server's responses must never be cached by the
+// browser) and, for HTML responses, injects the heartbeat/navigation
+// scripts into <head>.
+func (p *ProxyServer) modifyResponse(resp *http.Response) error {
+	resp.Header.Del("Cache-Control")
+	resp.Header.Del("ETag")
+	resp.Header.Del("Last-Modified")
+	resp.Header.Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate, max-age=0")
+	resp.Header.Set("Pragma", "no-cache")
+	resp.Header.Set("Expires", "0")
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(strings.ToLower(contentType), "text/html") {
+		return nil
 	}
-	defer clientConn.Close()
 
-	go func() {
-		io.Copy(targetConn, clientConn)
-	}()
-	io.Copy(clientConn, targetConn)
-}
+	log.Printf("Starting streaming HTML injection...")
 
-func (p *ProxyServer) proxyHTTPRequest(w http.ResponseWriter, r *http.Request) {
-	targetURL := fmt.Sprintf("http://localhost:%d%s", p.appPort, r.URL.RequestURI())
+	injector := html.NewHTMLInjector()
 
-	proxyReq, err := http.NewRequest(r.Method, targetURL, r.Body)
+	// Read and inject heartbeat script
+	heartbeatJS, err := p.readStaticFile("heartbeat.js")
 	if err != nil {
-		http.Error(w, "Failed to create proxy request", http.StatusInternalServerError)
-		return
-	}
-
-	for key, values := range r.Header {
-		for _, value := range values {
-			proxyReq.Header.Add(key, value)
-		}
+		log.Printf("Error reading heartbeat.js: %v", err)
+	} else {
+		injector.AddScript(html.ScriptConfig{
+			Content:     string(heartbeatJS),
+			InsertFirst: true,
+		})
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(proxyReq)
+	// Read and inject navigation script
+	navigationJS, err := p.readStaticFile("navigation.js")
 	if err != nil {
-		log.Printf("Failed to proxy request to app server: %v", err)
-		http.Error(w, "App server unavailable", http.StatusServiceUnavailable)
-		return
+		log.Printf("Error reading navigation.js: %v", err)
+	} else {
+		injector.AddScript(html.ScriptConfig{
+			Content:     string(navigationJS),
+			InsertFirst: true,
+		})
 	}
-	defer resp.Body.Close()
-
-	// Copy headers, but skip Content-Length and cache-related headers
-	for key, values := range resp.Header {
-		k := strings.ToLower(key)
-		if k != "content-length" &&
-			k != "cache-control" &&
-			k != "etag" &&
-			k != "last-modified" {
-			for _, value := range values {
-				w.Header().Add(key, value)
-			}
-		}
+
+	if p.liveReload.Load() {
+		injector.AddLiveReloadScript(reloadWSPath)
 	}
 
-	// Add cache control headers
-	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate, max-age=0")
-	w.Header().Set("Pragma", "no-cache")
-	w.Header().Set("Expires", "0")
+	// Stream the upstream body through the tokenizer rather than buffering
+	// it fully, so large index pages don't delay TTFB or risk OOMing. The
+	// ReverseProxy copies resp.Body to the client as it's written to pw, so
+	// there's no point where the whole document sits in memory.
+	upstreamBody := resp.Body
+	pr, pw := io.Pipe()
+	go func() {
+		err := injector.Stream(upstreamBody, pw)
+		upstreamBody.Close()
+		pw.CloseWithError(err)
+	}()
 
-	// Check if response is HTML
-	contentType := resp.Header.Get("Content-Type")
-	isHTML := strings.Contains(strings.ToLower(contentType), "text/html")
+	resp.Body = pr
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return nil
+}
 
-	if isHTML {
-		// Read the entire response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Error reading response body: %v", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// handleProxyError is invoked by the reverse proxy when the transport gives
+// up on the dev server (including after badGatewayTransport's retries are
+// exhausted). HTML-accepting requests get a friendly bad-gateway page so a
+// browser tab mid-restart doesn't show a bare connection error; everything
+// else gets a 502 with Retry-After so clients know to back off and retry.
+func (p *ProxyServer) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Failed to proxy request to app server: %v", err)
+	w.Header().Set("Retry-After", "2")
+
+	if wantsHTML(r) {
+		badGatewayHTML, ferr := p.readStaticFile("bad_gateway.html")
+		if ferr == nil {
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write(badGatewayHTML)
 			return
 		}
+		log.Printf("Error reading bad_gateway.html: %v", ferr)
+	}
+
+	http.Error(w, "App server unavailable", http.StatusBadGateway)
+}
 
-		log.Printf("Starting HTML injection process...")
+// badGatewayTransport retries transient dial/connection failures to the dev
+// server with exponential backoff before giving up and letting the reverse
+// proxy's ErrorHandler render a bad-gateway response. This mirrors how
+// workhorse's badgateway roundtripper rides out brief unicorn restarts.
+type badGatewayTransport struct {
+	inner   http.RoundTripper
+	backoff []time.Duration
+}
 
-		injector := html.NewHTMLInjector()
+func newBadGatewayTransport(inner http.RoundTripper) *badGatewayTransport {
+	return &badGatewayTransport{inner: inner, backoff: badGatewayBackoff}
+}
 
-		// Read and inject heartbeat script
-		heartbeatJS, err := p.readStaticFile("heartbeat.js")
-		if err != nil {
-			log.Printf("Error reading heartbeat.js: %v", err)
-		} else {
-			injector.AddScript(html.ScriptConfig{
-				Content:     string(heartbeatJS),
-				InsertFirst: true,
-			})
+func (t *badGatewayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, err := t.inner.RoundTrip(req)
+		if err == nil {
+			return resp, nil
 		}
+		lastErr = err
 
-		// Read and inject navigation script
-		navigationJS, err := p.readStaticFile("navigation.js")
-		if err != nil {
-			log.Printf("Error reading navigation.js: %v", err)
-		} else {
-			injector.AddScript(html.ScriptConfig{
-				Content:     string(navigationJS),
-				InsertFirst: true,
-			})
+		if attempt >= len(t.backoff) || !isRetryableDialError(err) {
+			return nil, lastErr
 		}
 
-		modifiedBody, err := injector.InjectIntoHTML(body)
-		if err != nil {
-			log.Printf("Error injecting HTML: %v", err)
-			// Fall back to sending original response
-			w.WriteHeader(resp.StatusCode)
-			w.Write(body)
-			return
-		}
+		time.Sleep(t.backoff[attempt])
 
-		// Write the modified response
-		w.WriteHeader(resp.StatusCode)
-		if _, err := w.Write(modifiedBody); err != nil {
-			log.Printf("Error writing modified response: %v", err)
-		}
-	} else {
-		// For non-HTML responses, simply copy the response as-is
-		w.WriteHeader(resp.StatusCode)
-		if _, err := io.Copy(w, resp.Body); err != nil {
-			log.Printf("Error copying response: %v", err)
+		// The previous attempt may have drained the request body; rewind it
+		// via GetBody so the retry sends the same payload.
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return nil, berr
+			}
+			req.Body = body
 		}
 	}
 }
 
+// isRetryableDialError reports whether err looks like a transient failure to
+// reach the dev server (connection refused, dial timeout, etc.) as opposed
+// to a permanent error that a retry won't fix.
+func isRetryableDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
 func FindFreePort() (int, error) {
 	addr, err := net.ResolveTCPAddr("tcp", "localhost:0")
 	if err != nil {
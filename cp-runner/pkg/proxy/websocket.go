@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 60 * time.Second
+)
+
+// wsConnSet tracks the client-side websocket connections currently being
+// proxied, so they can all be closed together when the dev server goes into
+// loading mode (e.g. during a restart).
+type wsConnSet struct {
+	mu    sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}
+
+func newWSConnSet() *wsConnSet {
+	return &wsConnSet{conns: make(map[*websocket.Conn]struct{})}
+}
+
+func (s *wsConnSet) add(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conns[conn] = struct{}{}
+}
+
+func (s *wsConnSet) remove(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+}
+
+// closeAll sends a 1013 "Try Again Later" close frame to every tracked
+// connection so clients reconnect against the loading page instead of
+// hanging on a connection to a dev server that's about to restart.
+func (s *wsConnSet) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	closeMsg := websocket.FormatCloseMessage(1013, "server restarting")
+	for conn := range s.conns {
+		_ = conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		conn.Close()
+	}
+}
+
+// handleWebSocket upgrades the client connection, dials the same path on
+// the dev server, and pumps messages in both directions, preserving
+// message types and forwarding negotiated subprotocols/cookies. It replaces
+// the old raw-TCP hijack, which broke once the upstream needed anything
+// beyond a bare passthrough (TLS termination, subprotocol negotiation,
+// keepalive pings).
+func (p *ProxyServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	upstreamConn, upstreamProtocol, err := p.dialUpstreamWebSocket(r)
+	if err != nil {
+		log.Printf("Failed to connect to app server websocket: %v", err)
+		http.Error(w, "app server unavailable", http.StatusBadGateway)
+		return
+	}
+	defer upstreamConn.Close()
+
+	// Echo back only the subprotocol the upstream handshake actually
+	// accepted, not whatever the client merely offered.
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+	if upstreamProtocol != "" {
+		upgrader.Subprotocols = []string{upstreamProtocol}
+	}
+
+	clientConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade client websocket connection: %v", err)
+		return
+	}
+	defer clientConn.Close()
+
+	p.wsConns.add(clientConn)
+	defer p.wsConns.remove(clientConn)
+
+	errc := make(chan error, 2)
+	go pumpWSMessages(upstreamConn, clientConn, errc)
+	go pumpWSMessages(clientConn, upstreamConn, errc)
+
+	clientConn.SetReadDeadline(time.Now().Add(wsPongWait))
+	clientConn.SetPongHandler(func(string) error {
+		clientConn.SetReadDeadline(time.Now().Add(wsPongWait))
+		return nil
+	})
+
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := clientConn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case err := <-errc:
+			if err != nil {
+				log.Printf("Websocket proxy connection closed: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// dialUpstreamWebSocket opens a websocket connection to the dev server at
+// the same path/query as the client request, forwarding cookies and any
+// subprotocols the client offered, and returns the subprotocol the upstream
+// handshake actually accepted (empty if none). Extensions aren't forwarded -
+// gorilla's dialer negotiates its own and rejects a hand-set
+// Sec-WebSocket-Extensions header as a duplicate.
+func (p *ProxyServer) dialUpstreamWebSocket(r *http.Request) (*websocket.Conn, string, error) {
+	targetURL := url.URL{
+		Scheme:   "ws",
+		Host:     fmt.Sprintf("localhost:%d", p.appPort),
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+	}
+
+	dialer := websocket.Dialer{
+		Subprotocols:     websocket.Subprotocols(r),
+		HandshakeTimeout: 10 * time.Second,
+	}
+
+	reqHeader := http.Header{}
+	if cookie := r.Header.Get("Cookie"); cookie != "" {
+		reqHeader.Set("Cookie", cookie)
+	}
+
+	conn, resp, err := dialer.Dial(targetURL.String(), reqHeader)
+	if err != nil {
+		return nil, "", err
+	}
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	resp.Body.Close()
+	return conn, protocol, nil
+}
+
+// pumpWSMessages copies messages (preserving their type) from src to dst
+// until a read or write fails, reporting the terminal error on errc.
+func pumpWSMessages(dst, src *websocket.Conn, errc chan<- error) {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			errc <- err
+			return
+		}
+		if err := dst.WriteMessage(messageType, data); err != nil {
+			errc <- err
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package remote resolves git hosting credentials for the control plane's
+// push/pull endpoints, following the same lookup chain as credential
+// helpers like jiri/pkgdashcli: prefer an explicit token, then whatever
+// the caller's HTTP session carries, then the user's netrc, then git's
+// configured cookie file.
+package remote
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jdx/go-netrc"
+)
+
+// Credentials is a resolved username/password pair for authenticating
+// against a git remote over HTTP(S).
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// ResolveCredentials finds credentials for authenticating to host, trying
+// each source in order and returning the first that applies:
+//
+//  1. an explicit token (e.g. from the request body)
+//  2. a Bearer/Basic/token Authorization header forwarded from the caller
+//  3. the host entry in $HOME/.netrc
+//  4. the path configured as git's http.cookiefile, read for a cookie matching host
+//
+// ok is false if none of these yield credentials.
+func ResolveCredentials(ctx context.Context, projectPath, host, token, authHeader string) (Credentials, bool) {
+	if token != "" {
+		return Credentials{Username: "x-access-token", Password: token}, true
+	}
+
+	if password, ok := tokenFromAuthHeader(authHeader); ok {
+		return Credentials{Username: "x-access-token", Password: password}, true
+	}
+
+	if creds, ok := credentialsFromNetrc(host); ok {
+		return creds, true
+	}
+
+	if password, ok := tokenFromCookieFile(ctx, projectPath, host); ok {
+		return Credentials{Username: "x-access-token", Password: password}, true
+	}
+
+	return Credentials{}, false
+}
+
+// tokenFromAuthHeader extracts the credential from a "Bearer <token>",
+// "Basic <token>" or "token <token>" Authorization header.
+func tokenFromAuthHeader(header string) (string, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return "", false
+	}
+	for _, prefix := range []string{"Bearer ", "Basic ", "token "} {
+		if strings.HasPrefix(header, prefix) {
+			return strings.TrimSpace(strings.TrimPrefix(header, prefix)), true
+		}
+	}
+	return header, true
+}
+
+// credentialsFromNetrc looks up host in $HOME/.netrc.
+func credentialsFromNetrc(host string) (Credentials, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Credentials{}, false
+	}
+
+	n, err := netrc.Parse(filepath.Join(home, ".netrc"))
+	if err != nil {
+		return Credentials{}, false
+	}
+
+	m := n.Machine(host)
+	if m == nil {
+		return Credentials{}, false
+	}
+
+	password := m.Get("password")
+	if password == "" {
+		return Credentials{}, false
+	}
+	login := m.Get("login")
+	if login == "" {
+		login = "x-access-token"
+	}
+	return Credentials{Username: login, Password: password}, true
+}
+
+// tokenFromCookieFile reads the path configured as git's http.cookiefile
+// for the repository at projectPath and returns the value of its cookie
+// for host, if any.
+func tokenFromCookieFile(ctx context.Context, projectPath, host string) (string, bool) {
+	cmd := exec.CommandContext(ctx, "git", "config", "--get", "http.cookiefile")
+	cmd.Dir = projectPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+
+	path := strings.TrimSpace(string(output))
+	if path == "" {
+		return "", false
+	}
+
+	return cookieForHost(path, host)
+}
+
+// cookieForHost scans a Netscape-format cookie file for a cookie
+// belonging to host and returns its value.
+func cookieForHost(path, host string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		if strings.TrimPrefix(fields[0], ".") == strings.TrimPrefix(host, ".") {
+			return fields[6], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,194 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenFromAuthHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOk bool
+	}{
+		{"empty", "", "", false},
+		{"bearer", "Bearer abc123", "abc123", true},
+		{"basic", "Basic dXNlcjpwYXNz", "dXNlcjpwYXNz", true},
+		{"token", "token xyz", "xyz", true},
+		{"bare", "just-a-token", "just-a-token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tokenFromAuthHeader(tt.header)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("tokenFromAuthHeader(%q) = (%q, %v), want (%q, %v)", tt.header, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCookieForHost(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cookies.txt")
+	content := "# Netscape HTTP Cookie File\n" +
+		"github.com\tTRUE\t/\tTRUE\t0\ttoken\tsecret-value\n" +
+		".gitlab.com\tTRUE\t/\tTRUE\t0\ttoken\tanother-value\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		host   string
+		want   string
+		wantOk bool
+	}{
+		{"exact match", "github.com", "secret-value", true},
+		{"leading dot on cookie, not on host", "gitlab.com", "another-value", true},
+		{"no match", "example.com", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cookieForHost(path, tt.host)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("cookieForHost(%q) = (%q, %v), want (%q, %v)", tt.host, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestCookieForHostMissingFile(t *testing.T) {
+	if _, ok := cookieForHost(filepath.Join(t.TempDir(), "missing.txt"), "github.com"); ok {
+		t.Errorf("ok = true for missing cookie file, want false")
+	}
+}
+
+func TestCredentialsFromNetrc(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	content := "machine github.com\n  login x-access-token\n  password s3cr3t\n"
+	if err := os.WriteFile(filepath.Join(home, ".netrc"), []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	creds, ok := credentialsFromNetrc("github.com")
+	if !ok {
+		t.Fatalf("ok = false, want true")
+	}
+	if creds.Username != "x-access-token" || creds.Password != "s3cr3t" {
+		t.Errorf("creds = %+v, want {x-access-token s3cr3t}", creds)
+	}
+
+	if _, ok := credentialsFromNetrc("example.com"); ok {
+		t.Errorf("ok = true for unknown host, want false")
+	}
+}
+
+func TestCredentialsFromNetrcMissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if _, ok := credentialsFromNetrc("github.com"); ok {
+		t.Errorf("ok = true with no .netrc, want false")
+	}
+}
+
+// initRepoWithCookieFile creates a git repo at a temp dir with
+// http.cookiefile configured, returning the repo path and the cookie file
+// path so tests can populate it.
+func initRepoWithCookieFile(t *testing.T) (repoPath, cookiePath string) {
+	t.Helper()
+	repoPath = t.TempDir()
+	if out, err := exec.Command("git", "init", repoPath).CombinedOutput(); err != nil {
+		t.Skipf("git init unavailable: %v: %s", err, out)
+	}
+
+	cookiePath = filepath.Join(t.TempDir(), "cookies.txt")
+	cmd := exec.Command("git", "config", "http.cookiefile", cookiePath)
+	cmd.Dir = repoPath
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	return repoPath, cookiePath
+}
+
+func TestTokenFromCookieFile(t *testing.T) {
+	repoPath, cookiePath := initRepoWithCookieFile(t)
+	content := "github.com\tTRUE\t/\tTRUE\t0\ttoken\tcookie-secret\n"
+	if err := os.WriteFile(cookiePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	password, ok := tokenFromCookieFile(context.Background(), repoPath, "github.com")
+	if !ok {
+		t.Fatalf("ok = false, want true")
+	}
+	if password != "cookie-secret" {
+		t.Errorf("password = %q, want cookie-secret", password)
+	}
+}
+
+func TestTokenFromCookieFileNoCookiefileConfigured(t *testing.T) {
+	repoPath := t.TempDir()
+	if out, err := exec.Command("git", "init", repoPath).CombinedOutput(); err != nil {
+		t.Skipf("git init unavailable: %v: %s", err, out)
+	}
+
+	if _, ok := tokenFromCookieFile(context.Background(), repoPath, "github.com"); ok {
+		t.Errorf("ok = true with no http.cookiefile configured, want false")
+	}
+}
+
+func TestResolveCredentialsPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	netrcContent := "machine github.com\n  login netrc-user\n  password netrc-pass\n"
+	if err := os.WriteFile(filepath.Join(home, ".netrc"), []byte(netrcContent), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	repoPath, cookiePath := initRepoWithCookieFile(t)
+	cookieContent := "github.com\tTRUE\t/\tTRUE\t0\ttoken\tcookie-pass\n" +
+		"gitlab.com\tTRUE\t/\tTRUE\t0\ttoken\tgitlab-cookie-pass\n"
+	if err := os.WriteFile(cookiePath, []byte(cookieContent), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	t.Run("explicit token wins over everything", func(t *testing.T) {
+		creds, ok := ResolveCredentials(context.Background(), repoPath, "github.com", "explicit-token", "Bearer header-token")
+		if !ok || creds.Password != "explicit-token" {
+			t.Errorf("creds = %+v, ok = %v, want explicit-token", creds, ok)
+		}
+	})
+
+	t.Run("auth header wins over netrc and cookie", func(t *testing.T) {
+		creds, ok := ResolveCredentials(context.Background(), repoPath, "github.com", "", "Bearer header-token")
+		if !ok || creds.Password != "header-token" {
+			t.Errorf("creds = %+v, ok = %v, want header-token", creds, ok)
+		}
+	})
+
+	t.Run("netrc wins over cookie file", func(t *testing.T) {
+		creds, ok := ResolveCredentials(context.Background(), repoPath, "github.com", "", "")
+		if !ok || creds.Password != "netrc-pass" || creds.Username != "netrc-user" {
+			t.Errorf("creds = %+v, ok = %v, want netrc-user/netrc-pass", creds, ok)
+		}
+	})
+
+	t.Run("falls back to cookie file when host has no netrc entry", func(t *testing.T) {
+		creds, ok := ResolveCredentials(context.Background(), repoPath, "gitlab.com", "", "")
+		if !ok || creds.Password != "gitlab-cookie-pass" {
+			t.Errorf("creds = %+v, ok = %v, want gitlab-cookie-pass", creds, ok)
+		}
+	})
+
+	t.Run("no source applies", func(t *testing.T) {
+		_, ok := ResolveCredentials(context.Background(), repoPath, "example.com", "", "")
+		if ok {
+			t.Errorf("ok = true, want false")
+		}
+	})
+}
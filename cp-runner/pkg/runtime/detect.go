@@ -0,0 +1,55 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allPackageManagers lists every known PackageManager, used for lockfile
+// detection in a deterministic order.
+var allPackageManagers = []PackageManager{PNPM, YARN, BUN, NPM}
+
+// packageJSONManifest is the subset of package.json fields DetectPackageManager cares about.
+type packageJSONManifest struct {
+	PackageManager string `json:"packageManager"`
+}
+
+// DetectPackageManager infers which package manager a project uses by
+// inspecting package.json's "packageManager" field (per the corepack spec,
+// e.g. "pnpm@8.15.0") and, failing that, by checking which lockfile is
+// present in projectPath.
+func DetectPackageManager(projectPath string) (PackageManager, error) {
+	if pm, ok := detectFromManifest(projectPath); ok {
+		return pm, nil
+	}
+
+	for _, pm := range allPackageManagers {
+		if _, err := os.Stat(filepath.Join(projectPath, pm.LockfileName())); err == nil {
+			return pm, nil
+		}
+	}
+
+	return nil, fmt.Errorf("could not detect a package manager for %s", projectPath)
+}
+
+func detectFromManifest(projectPath string) (PackageManager, bool) {
+	data, err := os.ReadFile(filepath.Join(projectPath, "package.json"))
+	if err != nil {
+		return nil, false
+	}
+
+	var manifest packageJSONManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.PackageManager == "" {
+		return nil, false
+	}
+
+	name, _, _ := strings.Cut(manifest.PackageManager, "@")
+	pm, err := ParsePackageManager(name)
+	if err != nil {
+		return nil, false
+	}
+	return pm, true
+}
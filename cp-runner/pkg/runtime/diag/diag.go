@@ -0,0 +1,42 @@
+// Package diag parses structured diagnostics (file/line/col/severity) out of
+// the raw stdout/stderr lines emitted by frontend build tools, so the
+// control plane can surface an errors panel without re-running lint/typecheck.
+package diag
+
+// Severity is the severity of a parsed Diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single structured error or warning extracted from a line
+// of dev-server output.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Col      int
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// Parser extracts zero or more Diagnostics from a single line of output.
+// Implementations that need to correlate a diagnostic with a file mentioned
+// on a preceding line (as ESLint's stylish reporter does) may keep that
+// state on the receiver.
+type Parser interface {
+	Parse(line string) []Diagnostic
+}
+
+// DefaultParsers returns a fresh set of parsers covering the diagnostic
+// formats emitted by the frameworks CodePanda projects commonly use: tsc,
+// ESLint's stylish reporter, and esbuild/Vite's oneline error format.
+func DefaultParsers() []Parser {
+	return []Parser{
+		&TSCParser{},
+		&ESLintParser{},
+		&ESBuildParser{},
+	}
+}
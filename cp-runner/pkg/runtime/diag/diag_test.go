@@ -0,0 +1,152 @@
+package diag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTSCParser(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []Diagnostic
+	}{
+		{
+			name: "error",
+			line: `src/App.tsx(12,5): error TS2322: Type 'string' is not assignable to type 'number'.`,
+			want: []Diagnostic{{
+				File:     "src/App.tsx",
+				Line:     12,
+				Col:      5,
+				Severity: SeverityError,
+				Code:     "TS2322",
+				Message:  "Type 'string' is not assignable to type 'number'.",
+			}},
+		},
+		{
+			name: "warning",
+			line: `src/index.ts(1,1): warning TS6133: 'foo' is declared but never used.`,
+			want: []Diagnostic{{
+				File:     "src/index.ts",
+				Line:     1,
+				Col:      1,
+				Severity: SeverityWarning,
+				Code:     "TS6133",
+				Message:  "'foo' is declared but never used.",
+			}},
+		},
+		{
+			name: "no match",
+			line: "Found 1 error.",
+			want: nil,
+		},
+	}
+
+	p := &TSCParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Parse(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestESBuildParser(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []Diagnostic
+	}{
+		{
+			name: "error",
+			line: `src/main.ts:3:7: error: Could not resolve "./missing"`,
+			want: []Diagnostic{{
+				File:     "src/main.ts",
+				Line:     3,
+				Col:      7,
+				Severity: SeverityError,
+				Message:  `Could not resolve "./missing"`,
+			}},
+		},
+		{
+			name: "warning",
+			line: `src/main.ts:10:2: warning: "foo" is never used`,
+			want: []Diagnostic{{
+				File:     "src/main.ts",
+				Line:     10,
+				Col:      2,
+				Severity: SeverityWarning,
+				Message:  `"foo" is never used`,
+			}},
+		},
+		{
+			name: "no match",
+			line: "building...",
+			want: nil,
+		},
+	}
+
+	p := &ESBuildParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Parse(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestESLintParser(t *testing.T) {
+	p := &ESLintParser{}
+
+	if got := p.Parse("/home/user/project/src/index.ts"); got != nil {
+		t.Fatalf("Parse(header) = %#v, want nil", got)
+	}
+
+	got := p.Parse("  12:5  error  'foo' is not defined  no-undef")
+	want := []Diagnostic{{
+		File:     "/home/user/project/src/index.ts",
+		Line:     12,
+		Col:      5,
+		Severity: SeverityError,
+		Code:     "no-undef",
+		Message:  "'foo' is not defined",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(issue) = %#v, want %#v", got, want)
+	}
+
+	got = p.Parse("  20:1  warning  Missing return type  @typescript-eslint/explicit-function-return-type")
+	want = []Diagnostic{{
+		File:     "/home/user/project/src/index.ts",
+		Line:     20,
+		Col:      1,
+		Severity: SeverityWarning,
+		Code:     "@typescript-eslint/explicit-function-return-type",
+		Message:  "Missing return type",
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(second issue under same header) = %#v, want %#v", got, want)
+	}
+
+	if got := p.Parse("✖ 2 problems (1 error, 1 warning)"); got != nil {
+		t.Fatalf("Parse(summary) = %#v, want nil", got)
+	}
+
+	// A new header switches the file subsequent issue lines attribute to.
+	p.Parse("/home/user/project/src/other.ts")
+	got = p.Parse("  1:1  error  missing semicolon  semi")
+	if got[0].File != "/home/user/project/src/other.ts" {
+		t.Errorf("File = %q, want new header file", got[0].File)
+	}
+}
+
+func TestDefaultParsers(t *testing.T) {
+	parsers := DefaultParsers()
+	if len(parsers) != 3 {
+		t.Fatalf("len(DefaultParsers()) = %d, want 3", len(parsers))
+	}
+}
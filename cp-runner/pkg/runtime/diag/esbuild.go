@@ -0,0 +1,38 @@
+package diag
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// esbuildLineRe matches esbuild/Vite's oneline diagnostic format:
+//
+//	src/main.ts:3:7: error: Could not resolve "./missing"
+var esbuildLineRe = regexp.MustCompile(`^(.+?):(\d+):(\d+):\s+(error|warning):\s+(.*)$`)
+
+// ESBuildParser parses the oneline diagnostic format shared by esbuild and
+// the Vite dev server's build error output.
+type ESBuildParser struct{}
+
+func (p *ESBuildParser) Parse(line string) []Diagnostic {
+	m := esbuildLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	lineNo, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	severity := SeverityWarning
+	if m[4] == "error" {
+		severity = SeverityError
+	}
+
+	return []Diagnostic{{
+		File:     m[1],
+		Line:     lineNo,
+		Col:      col,
+		Severity: severity,
+		Code:     "",
+		Message:  m[5],
+	}}
+}
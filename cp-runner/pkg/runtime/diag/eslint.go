@@ -0,0 +1,56 @@
+package diag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// eslintIssueRe matches a single issue line from ESLint's stylish reporter:
+//
+//	12:5  error  'foo' is not defined  no-undef
+var eslintIssueRe = regexp.MustCompile(`^\s+(\d+):(\d+)\s+(error|warning)\s+(.+?)\s{2,}(\S+)\s*$`)
+
+// ESLintParser parses ESLint's stylish output, which groups issue lines
+// under a file path header. Since the file isn't repeated on each issue
+// line, the parser tracks the most recently seen header.
+type ESLintParser struct {
+	mu          sync.Mutex
+	currentFile string
+}
+
+func (p *ESLintParser) Parse(line string) []Diagnostic {
+	if m := eslintIssueRe.FindStringSubmatch(line); m != nil {
+		lineNo, _ := strconv.Atoi(m[1])
+		col, _ := strconv.Atoi(m[2])
+		severity := SeverityWarning
+		if m[3] == "error" {
+			severity = SeverityError
+		}
+
+		p.mu.Lock()
+		file := p.currentFile
+		p.mu.Unlock()
+
+		return []Diagnostic{{
+			File:     file,
+			Line:     lineNo,
+			Col:      col,
+			Severity: severity,
+			Code:     m[5],
+			Message:  strings.TrimSpace(m[4]),
+		}}
+	}
+
+	// A file header is a non-indented, non-empty line that isn't the
+	// trailing problem-count summary.
+	trimmed := strings.TrimRight(line, "\r")
+	if trimmed != "" && !strings.HasPrefix(trimmed, " ") && !strings.Contains(trimmed, "problem") {
+		p.mu.Lock()
+		p.currentFile = trimmed
+		p.mu.Unlock()
+	}
+
+	return nil
+}
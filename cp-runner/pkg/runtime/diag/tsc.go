@@ -0,0 +1,37 @@
+package diag
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// tscLineRe matches tsc diagnostics of the form:
+//
+//	src/App.tsx(12,5): error TS2322: Type 'string' is not assignable to type 'number'.
+var tscLineRe = regexp.MustCompile(`^(.+?)\((\d+),(\d+)\):\s+(error|warning)\s+(TS\d+):\s+(.*)$`)
+
+// TSCParser parses tsc's default diagnostic output format.
+type TSCParser struct{}
+
+func (p *TSCParser) Parse(line string) []Diagnostic {
+	m := tscLineRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+
+	lineNo, _ := strconv.Atoi(m[2])
+	col, _ := strconv.Atoi(m[3])
+	severity := SeverityWarning
+	if m[4] == "error" {
+		severity = SeverityError
+	}
+
+	return []Diagnostic{{
+		File:     m[1],
+		Line:     lineNo,
+		Col:      col,
+		Severity: severity,
+		Code:     m[5],
+		Message:  m[6],
+	}}
+}
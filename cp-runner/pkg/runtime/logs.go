@@ -0,0 +1,106 @@
+package runtime
+
+import "sync"
+
+// logRingBufferBytes bounds how much output handleOutput retains for replay
+// to newly-subscribed SSE clients, so a chatty dev server can't grow the
+// backlog without bound.
+const logRingBufferBytes = 4 * 1024 * 1024
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// LogLine is a single line of dev-server output, tagged with the stream it
+// came from and a monotonic sequence number used as the SSE event id so
+// clients can resume with Last-Event-ID.
+type LogLine struct {
+	Seq    uint64 `json:"seq"`
+	Stream string `json:"stream"`
+	Text   string `json:"text"`
+}
+
+// logHub fans out published log lines to subscribers while retaining a
+// bounded backlog so a client that (re)connects gets recent history before
+// it starts seeing live updates.
+type logHub struct {
+	mu          sync.Mutex
+	maxBytes    int
+	size        int
+	nextSeq     uint64
+	lines       []LogLine
+	subscribers map[chan LogLine]struct{}
+}
+
+func newLogHub(maxBytes int) *logHub {
+	return &logHub{
+		maxBytes:    maxBytes,
+		subscribers: make(map[chan LogLine]struct{}),
+	}
+}
+
+// publish appends a line to the backlog and fans it out to every subscriber.
+// Slow subscribers have the line dropped rather than block the dev server's
+// output pump.
+func (h *logHub) publish(stream, text string) {
+	h.mu.Lock()
+
+	line := LogLine{Seq: h.nextSeq, Stream: stream, Text: text}
+	h.nextSeq++
+
+	h.lines = append(h.lines, line)
+	h.size += len(text)
+	for h.size > h.maxBytes && len(h.lines) > 1 {
+		h.size -= len(h.lines[0].Text)
+		h.lines = h.lines[1:]
+	}
+
+	subs := make([]chan LogLine, 0, len(h.subscribers))
+	for ch := range h.subscribers {
+		subs = append(subs, ch)
+	}
+	h.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// subscribe registers a new subscriber and returns a channel that receives
+// the relevant backlog (either everything, or only lines with Seq > after
+// when fromAfter is set) followed by live updates, plus an unsubscribe func.
+//
+// The backlog is sent and the subscriber registered in the same critical
+// section, with the channel sized to hold the whole backlog plus live
+// headroom - publish appends its line and snapshots the subscriber set under
+// the same lock, so a concurrent publish's line lands either in the replayed
+// backlog or in the live stream, and can never interleave with (and silently
+// drop) part of the backlog replay.
+func (h *logHub) subscribe(after uint64, fromAfter bool) (<-chan LogLine, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var backlog []LogLine
+	for _, line := range h.lines {
+		if !fromAfter || line.Seq > after {
+			backlog = append(backlog, line)
+		}
+	}
+
+	ch := make(chan LogLine, len(backlog)+256)
+	for _, line := range backlog {
+		ch <- line
+	}
+	h.subscribers[ch] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
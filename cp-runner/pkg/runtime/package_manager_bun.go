@@ -0,0 +1,28 @@
+package runtime
+
+// BunManager implements PackageManager for bun.
+type BunManager struct{}
+
+func (BunManager) Name() string { return "bun" }
+
+func (BunManager) InstallCmd() (string, []string) {
+	return "bun", []string{"install"}
+}
+
+func (BunManager) AddCmd(pkg string, dev bool) (string, []string) {
+	args := []string{"add"}
+	if dev {
+		args = append(args, "-d")
+	}
+	return "bun", append(args, pkg)
+}
+
+func (BunManager) RunScriptCmd(script string, args ...string) (string, []string) {
+	return "bun", append([]string{"run", script}, args...)
+}
+
+func (BunManager) TypeCheckCmd() (string, []string) {
+	return "bun", append([]string{"x", "tsc"}, tscTypeCheckArgs...)
+}
+
+func (BunManager) LockfileName() string { return "bun.lockb" }
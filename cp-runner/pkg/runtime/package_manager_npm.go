@@ -0,0 +1,39 @@
+package runtime
+
+// tscTypeCheckArgs are the tsc flags shared by every package manager's
+// TypeCheckCmd: a standalone, incremental, no-emit check against the app's
+// tsconfig.
+var tscTypeCheckArgs = []string{"--noEmit", "--incremental", "-p", "tsconfig.app.json"}
+
+// NpmManager implements PackageManager for npm.
+type NpmManager struct{}
+
+func (NpmManager) Name() string { return "npm" }
+
+func (NpmManager) InstallCmd() (string, []string) {
+	return "npm", []string{"install"}
+}
+
+func (NpmManager) AddCmd(pkg string, dev bool) (string, []string) {
+	args := []string{"install"}
+	if dev {
+		args = append(args, "--save-dev")
+	}
+	return "npm", append(args, pkg)
+}
+
+func (NpmManager) RunScriptCmd(script string, args ...string) (string, []string) {
+	cmdArgs := []string{"run", script}
+	if len(args) > 0 {
+		// npm requires a `--` separator before args meant for the script.
+		cmdArgs = append(cmdArgs, "--")
+		cmdArgs = append(cmdArgs, args...)
+	}
+	return "npm", cmdArgs
+}
+
+func (NpmManager) TypeCheckCmd() (string, []string) {
+	return "npm", append([]string{"exec", "--", "tsc"}, tscTypeCheckArgs...)
+}
+
+func (NpmManager) LockfileName() string { return "package-lock.json" }
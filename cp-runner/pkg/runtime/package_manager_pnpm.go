@@ -0,0 +1,28 @@
+package runtime
+
+// PnpmManager implements PackageManager for pnpm.
+type PnpmManager struct{}
+
+func (PnpmManager) Name() string { return "pnpm" }
+
+func (PnpmManager) InstallCmd() (string, []string) {
+	return "pnpm", []string{"install"}
+}
+
+func (PnpmManager) AddCmd(pkg string, dev bool) (string, []string) {
+	args := []string{"add"}
+	if dev {
+		args = append(args, "-D")
+	}
+	return "pnpm", append(args, pkg)
+}
+
+func (PnpmManager) RunScriptCmd(script string, args ...string) (string, []string) {
+	return "pnpm", append([]string{"run", script}, args...)
+}
+
+func (PnpmManager) TypeCheckCmd() (string, []string) {
+	return "pnpm", append([]string{"exec", "tsc"}, tscTypeCheckArgs...)
+}
+
+func (PnpmManager) LockfileName() string { return "pnpm-lock.yaml" }
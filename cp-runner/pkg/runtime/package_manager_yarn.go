@@ -0,0 +1,31 @@
+package runtime
+
+// YarnManager implements PackageManager for yarn (v1 CLI shape: scripts run
+// directly as `yarn <script>` rather than `yarn run <script>`, and there's
+// no `dlx`/`exec` equivalent, so type-checking invokes the tsc binary
+// yarn already resolves from node_modules/.bin).
+type YarnManager struct{}
+
+func (YarnManager) Name() string { return "yarn" }
+
+func (YarnManager) InstallCmd() (string, []string) {
+	return "yarn", []string{"install"}
+}
+
+func (YarnManager) AddCmd(pkg string, dev bool) (string, []string) {
+	args := []string{"add"}
+	if dev {
+		args = append(args, "--dev")
+	}
+	return "yarn", append(args, pkg)
+}
+
+func (YarnManager) RunScriptCmd(script string, args ...string) (string, []string) {
+	return "yarn", append([]string{script}, args...)
+}
+
+func (YarnManager) TypeCheckCmd() (string, []string) {
+	return "yarn", append([]string{"tsc"}, tscTypeCheckArgs...)
+}
+
+func (YarnManager) LockfileName() string { return "yarn.lock" }
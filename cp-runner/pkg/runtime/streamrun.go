@@ -0,0 +1,107 @@
+package runtime
+
+import "sync"
+
+// StreamEventType identifies what kind of event a StreamEvent carries.
+type StreamEventType string
+
+const (
+	StreamEventStdout StreamEventType = "stdout"
+	StreamEventStderr StreamEventType = "stderr"
+	StreamEventExit   StreamEventType = "exit"
+)
+
+// StreamEvent is a single line of output, or the terminal exit event, from
+// a streamed command run.
+type StreamEvent struct {
+	Type StreamEventType
+	Data string
+	Code int
+}
+
+// streamRun fans a single command invocation's output out to every
+// subscriber that attaches while it's in flight, so concurrent SSE clients
+// tail the same run instead of each starting their own process.
+type streamRun struct {
+	mu          sync.Mutex
+	subscribers map[chan StreamEvent]chan struct{}
+	backlog     []StreamEvent
+	done        bool
+}
+
+func newStreamRun() *streamRun {
+	return &streamRun{subscribers: make(map[chan StreamEvent]chan struct{})}
+}
+
+// publish appends event to the backlog and fans it out to every current
+// subscriber. Ordinary output lines are dropped if a subscriber's buffer is
+// full, but the terminal exit event is never dropped this way - without it a
+// backpressured client has no way to learn whether the run succeeded or
+// failed, so delivery blocks until either the event is delivered or the
+// subscriber detaches (e.g. its SSE client disconnected), and only then is
+// its channel closed.
+func (r *streamRun) publish(event StreamEvent) {
+	r.mu.Lock()
+	r.backlog = append(r.backlog, event)
+
+	subs := make(map[chan StreamEvent]chan struct{}, len(r.subscribers))
+	for ch, detached := range r.subscribers {
+		subs[ch] = detached
+	}
+
+	isExit := event.Type == StreamEventExit
+	if isExit {
+		r.done = true
+		r.subscribers = make(map[chan StreamEvent]chan struct{})
+	}
+	r.mu.Unlock()
+
+	for ch, detached := range subs {
+		if isExit {
+			select {
+			case ch <- event:
+			case <-detached:
+			}
+			close(ch)
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// subscribe returns a channel replaying the run's backlog so far followed
+// by live events, plus an unsubscribe func. If the run has already
+// finished, the channel is closed once the backlog has been delivered.
+func (r *streamRun) subscribe() (<-chan StreamEvent, func()) {
+	detached := make(chan struct{})
+	var detachOnce sync.Once
+
+	// The backlog is queued and the subscriber registered under the same
+	// lock publish takes, with room for the whole backlog, so no live event
+	// (or the exit event's close) can get ahead of the replay.
+	r.mu.Lock()
+	ch := make(chan StreamEvent, len(r.backlog)+256)
+	for _, event := range r.backlog {
+		ch <- event
+	}
+	done := r.done
+	if !done {
+		r.subscribers[ch] = detached
+	}
+	r.mu.Unlock()
+
+	if done {
+		close(ch)
+	}
+
+	unsubscribe := func() {
+		r.mu.Lock()
+		delete(r.subscribers, ch)
+		r.mu.Unlock()
+		detachOnce.Do(func() { close(detached) })
+	}
+	return ch, unsubscribe
+}
@@ -0,0 +1,194 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// ProcessState is the lifecycle state of the supervised dev server process.
+type ProcessState int
+
+const (
+	StateStopped ProcessState = iota
+	StateStarting
+	StateRunning
+	StateBackoff
+	StateFatal
+)
+
+func (s ProcessState) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateBackoff:
+		return "backoff"
+	case StateFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// defaultStartSeconds is how long a freshly launched dev server is given
+	// to prove it's stable. An exit before this elapses on the very first
+	// attempt is treated as a misconfiguration rather than a flake, so it
+	// goes straight to Fatal instead of consuming a retry.
+	defaultStartSeconds = 5 * time.Second
+
+	// defaultStartRetries is how many times the dev server is relaunched
+	// after an unexpected exit before giving up and going Fatal.
+	defaultStartRetries = 3
+)
+
+// State returns the dev server's current supervision state.
+func (s *Supervisor) State() ProcessState {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return s.state
+}
+
+// LastFailure returns the stderr tail and error that most recently drove the
+// dev server into the Fatal state, or "" if it has never gone Fatal.
+func (s *Supervisor) LastFailure() string {
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return s.lastFailure
+}
+
+// OnStateChange registers fn to be called, synchronously, every time the dev
+// server's supervision state changes. Only one callback is kept; a later
+// call replaces an earlier one.
+func (s *Supervisor) OnStateChange(fn func(ProcessState)) {
+	s.stateMutex.Lock()
+	s.onStateChange = fn
+	s.stateMutex.Unlock()
+}
+
+func (s *Supervisor) setState(state ProcessState) {
+	s.stateMutex.Lock()
+	s.state = state
+	cb := s.onStateChange
+	s.stateMutex.Unlock()
+	if cb != nil {
+		cb(state)
+	}
+}
+
+func (s *Supervisor) setLastFailure(reason string) {
+	s.stateMutex.Lock()
+	s.lastFailure = reason
+	s.stateMutex.Unlock()
+}
+
+// launchDevServer starts the dev server once and spawns the goroutine that
+// watches it for an unexpected exit. firstAttempt marks the very first
+// launch of a supervision run, which is what decides whether an early exit
+// goes straight to Fatal or consumes a retry.
+func (s *Supervisor) launchDevServer(ctx context.Context, port int, firstAttempt bool) error {
+	s.setState(StateStarting)
+
+	name, args := s.pkgManager.RunScriptCmd("dev", "--port", fmt.Sprintf("%d", port))
+	cmd := exec.Command(name, args...)
+	cmd.Dir = s.projectPath
+	cmd.Env = os.Environ()
+	// Run the dev server in its own process group so StopCurrentProcess can
+	// signal it and every process it spawns (pnpm's own Node child, in
+	// particular) instead of just the direct child, which pnpm otherwise
+	// leaves orphaned to be reparented.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start dev server: %w", err)
+	}
+
+	done := make(chan struct{})
+	s.cmdMutex.Lock()
+	s.currentCmd = cmd
+	s.currentDone = done
+	s.cmdMutex.Unlock()
+
+	go s.handleOutput(stdout, StreamStdout)
+	go s.handleOutput(stderr, StreamStderr)
+
+	s.setState(StateRunning)
+
+	go s.monitorDevServer(ctx, cmd, done, port, time.Now(), firstAttempt)
+
+	return nil
+}
+
+// monitorDevServer waits for cmd to exit and decides whether to relaunch it,
+// back off and retry, or declare it Fatal. done is closed once cmd.Wait()
+// returns, letting StopCurrentProcess observe the exit without itself
+// calling Wait on the same *exec.Cmd.
+func (s *Supervisor) monitorDevServer(ctx context.Context, cmd *exec.Cmd, done chan struct{}, port int, startedAt time.Time, firstAttempt bool) {
+	waitErr := cmd.Wait()
+	close(done)
+
+	if ctx.Err() != nil {
+		// StopCurrentProcess or a deliberate restart canceled ctx; the exit
+		// was requested, not unexpected, so there's nothing to supervise.
+		return
+	}
+
+	s.cmdMutex.Lock()
+	if s.currentCmd == cmd {
+		s.currentCmd = nil
+	}
+	s.cmdMutex.Unlock()
+
+	ran := time.Since(startedAt)
+	if firstAttempt && ran < s.startSeconds {
+		s.failFatal(fmt.Sprintf("dev server exited after %s, before the %s startup grace period: %v", ran.Round(time.Millisecond), s.startSeconds, waitErr))
+		return
+	}
+
+	s.stateMutex.Lock()
+	s.retriesLeft--
+	retriesLeft := s.retriesLeft
+	attempt := s.startRetries - retriesLeft
+	s.stateMutex.Unlock()
+
+	if retriesLeft < 0 {
+		s.failFatal(fmt.Sprintf("dev server exited and exhausted %d retries: %v", s.startRetries, waitErr))
+		return
+	}
+
+	s.setState(StateBackoff)
+	backoff := time.Duration(1<<uint(attempt)) * time.Second
+
+	select {
+	case <-ctx.Done():
+		s.setState(StateStopped)
+		return
+	case <-time.After(backoff):
+	}
+
+	if err := s.launchDevServer(ctx, port, false); err != nil {
+		s.failFatal(fmt.Sprintf("failed to relaunch dev server: %v", err))
+	}
+}
+
+func (s *Supervisor) failFatal(reason string) {
+	s.setLastFailure(reason)
+	s.setState(StateFatal)
+}
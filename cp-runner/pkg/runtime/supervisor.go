@@ -2,149 +2,324 @@ package runtime
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/jjleng/cp-runner/pkg/runtime/diag"
 )
 
 type Supervisor struct {
-	projectPath   string
-	pkgManager    PackageManager
-	currentCmd    *exec.Cmd
+	projectPath string
+	pkgManager  PackageManager
+	currentCmd  *exec.Cmd
+	// currentDone is closed by the monitor goroutine once currentCmd.Wait()
+	// returns, so StopCurrentProcess can wait for the exit without racing a
+	// second call to Wait on the same *exec.Cmd.
+	currentDone   chan struct{}
 	cmdMutex      sync.Mutex
 	processOutput string
+	logs          *logHub
+	diagParsers   []diag.Parser
+	diagMutex     sync.Mutex
+	diagnostics   []diag.Diagnostic
+	streamMu      sync.Mutex
+	streamRuns    map[string]*streamRun
+
+	// state tracks the supervised dev server's lifecycle (see
+	// supervise.go). stopCancel cancels the currently supervised run so its
+	// monitor goroutine knows an exit was requested rather than unexpected.
+	stateMutex    sync.Mutex
+	state         ProcessState
+	lastFailure   string
+	onStateChange func(ProcessState)
+	startSeconds  time.Duration
+	startRetries  int
+	retriesLeft   int
+	stopCancel    context.CancelFunc
 }
 
 func NewSupervisor(projectPath string, pkgManager PackageManager) *Supervisor {
 	return &Supervisor{
-		projectPath: projectPath,
-		pkgManager:  pkgManager,
+		projectPath:  projectPath,
+		pkgManager:   pkgManager,
+		logs:         newLogHub(logRingBufferBytes),
+		diagParsers:  diag.DefaultParsers(),
+		streamRuns:   make(map[string]*streamRun),
+		startSeconds: defaultStartSeconds,
+		startRetries: defaultStartRetries,
 	}
 }
 
+// StartDevServer launches the dev server on port, supervising it for the
+// rest of its life: an unexpected exit is automatically relaunched with
+// exponential backoff, up to StartRetries times, unless it exits before
+// StartSeconds has elapsed on the first attempt, which goes straight to
+// Fatal. Call State()/LastFailure() to observe the outcome and
+// StopCurrentProcess to end supervision.
 func (s *Supervisor) StartDevServer(port int) error {
-	s.cmdMutex.Lock()
-	defer s.cmdMutex.Unlock()
-
 	if s.currentCmd != nil {
 		s.StopCurrentProcess()
 	}
 
-	cmd := exec.Command(s.pkgManager.String(), "run", "dev", "--port", fmt.Sprintf("%d", port))
-	cmd.Dir = s.projectPath
-	cmd.Env = os.Environ()
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start dev server: %w", err)
-	}
+	s.diagMutex.Lock()
+	s.diagnostics = nil
+	s.diagMutex.Unlock()
 
-	s.currentCmd = cmd
+	ctx, cancel := context.WithCancel(context.Background())
 
-	// Handle output in goroutines
-	go s.handleOutput(stdout)
-	go s.handleOutput(stderr)
+	s.stateMutex.Lock()
+	s.retriesLeft = s.startRetries
+	s.lastFailure = ""
+	s.stopCancel = cancel
+	s.stateMutex.Unlock()
 
-	return nil
+	return s.launchDevServer(ctx, port, true)
 }
 
 func (s *Supervisor) StopCurrentProcess() bool {
+	// Cancel supervision first so the monitor goroutine treats whatever
+	// happens next as a requested stop rather than an unexpected exit that
+	// needs retrying.
+	s.stateMutex.Lock()
+	if s.stopCancel != nil {
+		s.stopCancel()
+		s.stopCancel = nil
+	}
+	s.stateMutex.Unlock()
+	defer s.setState(StateStopped)
+
 	s.cmdMutex.Lock()
 	defer s.cmdMutex.Unlock()
 
 	if s.currentCmd != nil && s.currentCmd.Process != nil {
-		// Create channels for completion
-		done := make(chan error, 1)
-		processExited := make(chan struct{})
+		processExited := s.currentDone
+		pgid := s.currentCmd.Process.Pid // Setpgid makes the leader's pid the pgid
 
-		// First try to interrupt the process gracefully
-		if err := s.currentCmd.Process.Signal(os.Interrupt); err != nil {
-			log.Printf("Failed to send interrupt signal: %v", err)
+		// First try to terminate the whole process group gracefully, so
+		// pnpm's own Node child exits too instead of being reparented.
+		if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+			log.Printf("Failed to send SIGTERM to process group: %v", err)
 		}
 
-		// Start a goroutine to wait for the process
-		go func() {
-			done <- s.currentCmd.Wait()
-			close(processExited)
-		}()
-
 		// Wait for process to exit with timeout
 		select {
 		case <-time.After(5 * time.Second):
-			// If timeout, force kill the process
-			log.Printf("Process did not exit after interrupt, killing forcefully")
-			if err := s.currentCmd.Process.Kill(); err != nil {
-				log.Printf("Failed to kill process: %v", err)
+			// If timeout, force kill the whole process group
+			log.Printf("Process group did not exit after SIGTERM, killing forcefully")
+			if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil {
+				log.Printf("Failed to kill process group: %v", err)
 				return false
 			}
 			// Wait for kill to take effect with another timeout
 			select {
 			case <-processExited:
-				log.Printf("Process killed successfully")
+				log.Printf("Process group killed successfully")
 			case <-time.After(2 * time.Second):
-				log.Printf("Process kill operation timed out")
+				log.Printf("Process group kill operation timed out")
 				return false
 			}
-		case err := <-done:
-			if err != nil {
-				log.Printf("Process exited with error: %v", err)
-			} else {
-				log.Printf("Process exited gracefully")
-			}
+		case <-processExited:
+			log.Printf("Process exited")
 		}
 
 		// Clear process state
 		s.currentCmd = nil
+		s.currentDone = nil
 		s.processOutput = "" // Clear output buffer
 		return true
 	}
 
 	// No process to stop
 	s.currentCmd = nil
+	s.currentDone = nil
 	s.processOutput = ""
 	return false
 }
 
-func (s *Supervisor) RunLint() (bool, string) {
-	cmd := exec.Command(s.pkgManager.String(), "run", "lint")
+func (s *Supervisor) RunLint(ctx context.Context) (bool, string) {
+	name, args := s.pkgManager.RunScriptCmd("lint")
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = s.projectPath
 	output, err := cmd.CombinedOutput()
 	return err == nil, string(output)
 }
 
-func (s *Supervisor) CheckBuildErrors() (bool, string) {
+func (s *Supervisor) CheckBuildErrors(ctx context.Context) (bool, string) {
 	// Running the full build command is expensive, so we'll just run the type check command
-	typeCheckCmd := s.pkgManager.GetTypeCheckCmd()
-
-	// Run the command using sh -c to ensure shell expansion works properly
-	cmd := exec.Command("sh", "-c", typeCheckCmd)
+	name, args := s.pkgManager.TypeCheckCmd()
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = s.projectPath
 	output, err := cmd.CombinedOutput()
 	return err == nil, string(output)
 }
 
-func (s *Supervisor) handleOutput(pipe io.Reader) {
+// RunLintStream runs the project's lint script, or attaches to one already
+// in flight, streaming stdout/stderr lines and a final exit event.
+func (s *Supervisor) RunLintStream(ctx context.Context) (<-chan StreamEvent, func()) {
+	name, args := s.pkgManager.RunScriptCmd("lint")
+	return s.streamCommand(ctx, "lint", name, args, nil)
+}
+
+// CheckBuildErrorsStream runs the project's type check, or attaches to one
+// already in flight, streaming output the same way RunLintStream does.
+func (s *Supervisor) CheckBuildErrorsStream(ctx context.Context) (<-chan StreamEvent, func()) {
+	name, args := s.pkgManager.TypeCheckCmd()
+	return s.streamCommand(ctx, "check-build", name, args, nil)
+}
+
+// AddPackageStream installs packageName, or attaches to an install already
+// in flight, streaming output the same way RunLintStream does. onStart, if
+// non-nil, is called synchronously only when this call is the one that
+// actually launches a new install rather than attaching to one already in
+// flight; its return value is called once that install finishes. The
+// caller uses this to hold the same "dependencies" gate AddPackage and
+// RestartProject's install step use, for exactly the life of the real
+// process, so none of them race on the lockfile.
+func (s *Supervisor) AddPackageStream(ctx context.Context, packageName string, dev bool, onStart func() func()) (<-chan StreamEvent, func()) {
+	name, args := s.pkgManager.AddCmd(packageName, dev)
+	return s.streamCommand(ctx, "add-package", name, args, onStart)
+}
+
+// streamCommand attaches to the in-flight run for kind, if any, or starts
+// a new one and returns a subscription to it. onStart, if non-nil, is
+// invoked exactly once, synchronously, when a new run is actually started;
+// its return value is invoked once that run finishes.
+func (s *Supervisor) streamCommand(ctx context.Context, kind, name string, args []string, onStart func() func()) (<-chan StreamEvent, func()) {
+	s.streamMu.Lock()
+	if run, ok := s.streamRuns[kind]; ok {
+		s.streamMu.Unlock()
+		return run.subscribe()
+	}
+
+	var onEnd func()
+	if onStart != nil {
+		onEnd = onStart()
+	}
+
+	run := newStreamRun()
+	s.streamRuns[kind] = run
+	s.streamMu.Unlock()
+
+	go s.executeStreamed(ctx, kind, run, name, args, onEnd)
+
+	return run.subscribe()
+}
+
+// executeStreamed runs name/args to completion, publishing each output
+// line and a final exit event to run, then deregisters run as the
+// in-flight run for kind and calls onEnd, if non-nil.
+func (s *Supervisor) executeStreamed(ctx context.Context, kind string, run *streamRun, name string, args []string, onEnd func()) {
+	defer func() {
+		s.streamMu.Lock()
+		if s.streamRuns[kind] == run {
+			delete(s.streamRuns, kind)
+		}
+		s.streamMu.Unlock()
+		if onEnd != nil {
+			onEnd()
+		}
+	}()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = s.projectPath
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		run.publish(StreamEvent{Type: StreamEventExit, Data: err.Error(), Code: -1})
+		return
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		run.publish(StreamEvent{Type: StreamEventExit, Data: err.Error(), Code: -1})
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		run.publish(StreamEvent{Type: StreamEventExit, Data: err.Error(), Code: -1})
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); pumpStreamedOutput(stdout, StreamEventStdout, run) }()
+	go func() { defer wg.Done(); pumpStreamedOutput(stderr, StreamEventStderr, run) }()
+	wg.Wait()
+
+	code := 0
+	if err := cmd.Wait(); err != nil {
+		code = 1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+	}
+	run.publish(StreamEvent{Type: StreamEventExit, Code: code})
+}
+
+// pumpStreamedOutput publishes each line read from pipe as a StreamEvent
+// of the given type.
+func pumpStreamedOutput(pipe io.Reader, eventType StreamEventType, run *streamRun) {
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		run.publish(StreamEvent{Type: eventType, Data: scanner.Text()})
+	}
+}
+
+func (s *Supervisor) handleOutput(pipe io.Reader, stream string) {
 	scanner := bufio.NewScanner(pipe)
 	for scanner.Scan() {
+		line := scanner.Text()
+
 		s.cmdMutex.Lock()
-		s.processOutput += scanner.Text() + "\n"
+		s.processOutput += line + "\n"
 		s.cmdMutex.Unlock()
+
+		s.logs.publish(stream, line)
+
+		for _, parser := range s.diagParsers {
+			diagnostics := parser.Parse(line)
+			if len(diagnostics) == 0 {
+				continue
+			}
+			s.diagMutex.Lock()
+			s.diagnostics = append(s.diagnostics, diagnostics...)
+			s.diagMutex.Unlock()
+		}
 	}
 }
 
+// LatestDiagnostics returns the structured diagnostics parsed from dev
+// server output since it was last (re)started.
+func (s *Supervisor) LatestDiagnostics() []diag.Diagnostic {
+	s.diagMutex.Lock()
+	defer s.diagMutex.Unlock()
+	out := make([]diag.Diagnostic, len(s.diagnostics))
+	copy(out, s.diagnostics)
+	return out
+}
+
+// Subscribe returns a channel that immediately receives the backlogged log
+// lines followed by live output as it's produced, plus an unsubscribe func
+// that must be called once the caller is done reading.
+func (s *Supervisor) Subscribe() (<-chan LogLine, func()) {
+	return s.logs.subscribe(0, false)
+}
+
+// SubscribeAfter is like Subscribe but only replays backlog lines with a
+// sequence number greater than after, for resuming an SSE stream from a
+// client-supplied Last-Event-ID.
+func (s *Supervisor) SubscribeAfter(after uint64) (<-chan LogLine, func()) {
+	return s.logs.subscribe(after, true)
+}
+
 func (s *Supervisor) GetProjectPath() string {
 	return s.projectPath
 }
@@ -167,12 +342,13 @@ func (s *Supervisor) ClearOutput() {
 }
 
 // InstallDependencies installs project dependencies
-func (s *Supervisor) InstallDependencies() error {
+func (s *Supervisor) InstallDependencies(ctx context.Context) error {
 	if !s.Exists() {
 		return fmt.Errorf("project directory does not exist: %s", s.projectPath)
 	}
 
-	cmd := exec.Command(s.pkgManager.String(), "install")
+	name, args := s.pkgManager.InstallCmd()
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = s.projectPath
 
 	output, err := cmd.CombinedOutput()
@@ -188,13 +364,14 @@ func (s *Supervisor) GetPackageManager() PackageManager {
 	return s.pkgManager
 }
 
-// AddPackage installs a specific package
-func (s *Supervisor) AddPackage(packageName string) (bool, string) {
+// AddPackage installs a specific package, optionally as a dev dependency
+func (s *Supervisor) AddPackage(ctx context.Context, packageName string, dev bool) (bool, string) {
 	if !s.Exists() {
 		return false, fmt.Sprintf("project directory does not exist: %s", s.projectPath)
 	}
 
-	cmd := exec.Command(s.pkgManager.String(), "add", packageName)
+	name, args := s.pkgManager.AddCmd(packageName, dev)
+	cmd := exec.CommandContext(ctx, name, args...)
 	cmd.Dir = s.projectPath
 
 	output, err := cmd.CombinedOutput()
@@ -205,6 +382,24 @@ func (s *Supervisor) AddPackage(packageName string) (bool, string) {
 	return true, string(output)
 }
 
+// KillProcessGroup immediately SIGKILLs the dev server's process group, if
+// one is running, without waiting for it to exit. Used to force a clean
+// process exit when graceful shutdown is escalated, e.g. by a second
+// interrupt signal, instead of going through StopCurrentProcess's
+// SIGTERM-then-wait sequence.
+func (s *Supervisor) KillProcessGroup() {
+	s.cmdMutex.Lock()
+	cmd := s.currentCmd
+	s.cmdMutex.Unlock()
+
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		log.Printf("Failed to kill process group: %v", err)
+	}
+}
+
 // IsProcessRunning checks if there's a process currently running
 func (s *Supervisor) IsProcessRunning() bool {
 	s.cmdMutex.Lock()
@@ -2,14 +2,43 @@ package runtime
 
 import "fmt"
 
-type PackageManager int
-
-const (
-	NPM PackageManager = iota
-	YARN
-	PNPM
+// PackageManager abstracts over the CLI shape of npm/pnpm/yarn/bun so
+// Supervisor doesn't have to hardcode any one manager's commands or flags.
+// Each method returns a binary name and argv ready for exec.Command.
+type PackageManager interface {
+	// Name returns the package manager's CLI binary name (npm, pnpm, yarn, bun).
+	Name() string
+
+	// InstallCmd returns the command that installs all dependencies from
+	// the lockfile/manifest.
+	InstallCmd() (string, []string)
+
+	// AddCmd returns the command that adds pkg as a dependency, optionally
+	// as a dev dependency.
+	AddCmd(pkg string, dev bool) (string, []string)
+
+	// RunScriptCmd returns the command that runs the named package.json
+	// script, forwarding any extra args to the script itself.
+	RunScriptCmd(script string, args ...string) (string, []string)
+
+	// TypeCheckCmd returns the command used to run a standalone tsc
+	// type-check (no emit) against tsconfig.app.json.
+	TypeCheckCmd() (string, []string)
+
+	// LockfileName returns the lockfile this package manager looks for,
+	// used by DetectPackageManager.
+	LockfileName() string
+}
+
+var (
+	NPM  PackageManager = NpmManager{}
+	YARN PackageManager = YarnManager{}
+	PNPM PackageManager = PnpmManager{}
+	BUN  PackageManager = BunManager{}
 )
 
+// ParsePackageManager resolves a package manager by its CLI name, as passed
+// on the --package-manager flag.
 func ParsePackageManager(s string) (PackageManager, error) {
 	switch s {
 	case "npm":
@@ -18,94 +47,9 @@ func ParsePackageManager(s string) (PackageManager, error) {
 		return YARN, nil
 	case "pnpm":
 		return PNPM, nil
+	case "bun":
+		return BUN, nil
 	default:
-		return PackageManager(-1), fmt.Errorf("invalid package manager: %s", s)
-	}
-}
-
-func (pm PackageManager) IsValid() bool {
-	switch pm {
-	case NPM, YARN, PNPM:
-		return true
-	default:
-		return false
-	}
-}
-
-func (pm PackageManager) String() string {
-	switch pm {
-	case NPM:
-		return "npm"
-	case YARN:
-		return "yarn"
-	case PNPM:
-		return "pnpm"
-	default:
-		return "unknown"
-	}
-}
-
-func (pm PackageManager) GetInstallCmd() string {
-	switch pm {
-	case NPM:
-		return "npm install"
-	case YARN:
-		return "yarn install"
-	case PNPM:
-		return "pnpm install"
-	default:
-		return ""
-	}
-}
-
-func (pm PackageManager) GetDevCmd() string {
-	switch pm {
-	case NPM:
-		return "npm run dev"
-	case YARN:
-		return "yarn dev"
-	case PNPM:
-		return "pnpm dev"
-	default:
-		return ""
-	}
-}
-
-func (pm PackageManager) GetLintCmd() string {
-	switch pm {
-	case NPM:
-		return "npm run lint"
-	case YARN:
-		return "yarn lint"
-	case PNPM:
-		return "pnpm lint"
-	default:
-		return ""
-	}
-}
-
-func (pm PackageManager) GetBuildCmd() string {
-	switch pm {
-	case NPM:
-		return "npm run build"
-	case YARN:
-		return "yarn build"
-	case PNPM:
-		return "pnpm build"
-	default:
-		return ""
-	}
-}
-
-func (pm PackageManager) GetTypeCheckCmd() string {
-	switch pm {
-	case NPM:
-		return "npm exec -- tsc --noEmit --incremental -p tsconfig.app.json"
-	case YARN:
-		return "yarn tsc --noEmit --incremental -p tsconfig.app.json"
-	case PNPM:
-		return "pnpm exec tsc --noEmit --incremental -p tsconfig.app.json"
-	default:
-		return ""
+		return nil, fmt.Errorf("invalid package manager: %s", s)
 	}
 }
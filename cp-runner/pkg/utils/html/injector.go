@@ -3,6 +3,7 @@ package html
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 
@@ -33,6 +34,73 @@ func (i *HTMLInjector) AddScript(config ScriptConfig) {
 	i.scripts = append(i.scripts, config)
 }
 
+// liveReloadScriptTemplate is a minimal websocket client. It reconnects to
+// endpoint (a path on the page's own host) on every close, reloads the page
+// on a {"type":"reload"} message, and on a {"type":"css","path":"..."}
+// message hot-swaps the <link> stylesheet whose href contains path by
+// cloning it with a cache-busting query param, falling back to a full
+// reload if no matching stylesheet is found.
+const liveReloadScriptTemplate = `(function() {
+  function connect() {
+    var proto = location.protocol === "https:" ? "wss:" : "ws:";
+    var ws = new WebSocket(proto + "//" + location.host + %q);
+    ws.onmessage = function(event) {
+      var msg;
+      try { msg = JSON.parse(event.data); } catch (e) { return; }
+      if (msg.type === "css" && msg.path) {
+        var links = document.querySelectorAll('link[rel="stylesheet"]');
+        for (var i = 0; i < links.length; i++) {
+          var link = links[i];
+          if (link.href.indexOf(msg.path) !== -1) {
+            var next = link.cloneNode();
+            var url = new URL(link.href, location.href);
+            url.searchParams.set("_cp_reload", Date.now());
+            next.href = url.toString();
+            next.onload = function() { link.remove(); };
+            link.parentNode.insertBefore(next, link.nextSibling);
+            return;
+          }
+        }
+        location.reload();
+      } else if (msg.type === "reload") {
+        location.reload();
+      }
+    };
+    ws.onclose = function() { setTimeout(connect, 1000); };
+  }
+  connect();
+})();`
+
+// AddLiveReloadScript adds the live-reload client script, which opens a
+// websocket at endpoint (a path on the page's own host, e.g.
+// "/__cp_reload") and reloads the page or hot-swaps a stylesheet when the
+// control plane pushes a reload/css event.
+func (i *HTMLInjector) AddLiveReloadScript(endpoint string) {
+	i.AddScript(ScriptConfig{
+		Content:     fmt.Sprintf(liveReloadScriptTemplate, endpoint),
+		InsertFirst: true,
+	})
+}
+
+// orderedScripts returns the configured scripts in the order they should
+// appear in <head>. Scripts with InsertFirst are each conceptually inserted
+// at the front of head, so the last one added ends up first; scripts
+// without InsertFirst keep the order they were added in, after those.
+func (i *HTMLInjector) orderedScripts() []ScriptConfig {
+	ordered := make([]ScriptConfig, 0, len(i.scripts))
+	for idx := len(i.scripts) - 1; idx >= 0; idx-- {
+		if i.scripts[idx].InsertFirst {
+			ordered = append(ordered, i.scripts[idx])
+		}
+	}
+	for _, script := range i.scripts {
+		if !script.InsertFirst {
+			ordered = append(ordered, script)
+		}
+	}
+	return ordered
+}
+
 // ensureHead ensures that the document has a head element, creating it if necessary
 func ensureHead(doc *html.Node) (*html.Node, error) {
 	// First try to find existing head
@@ -145,3 +213,86 @@ func (i *HTMLInjector) InjectIntoHTML(content []byte) ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// Stream copies content from r to w token-by-token using golang.org/x/net/html's
+// Tokenizer, forwarding every token's raw bytes verbatim so the original
+// formatting is preserved. On the first <head> start tag (or, if the
+// document has no <head>, the first <html> start tag) it emits the
+// configured script tags before continuing to stream. Unlike InjectIntoHTML,
+// this never buffers the full document in memory, so it scales to large SPA
+// index pages without adding to TTFB.
+func (i *HTMLInjector) Stream(r io.Reader, w io.Writer) error {
+	z := html.NewTokenizer(r)
+	injected := false
+	sawHTML := false
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return fmt.Errorf("failed to tokenize HTML: %w", err)
+			}
+			return nil
+		}
+
+		var name string
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken || tt == html.EndTagToken {
+			tagName, _ := z.TagName()
+			name = string(tagName)
+		}
+
+		// An <html> not followed by <head> gets the scripts just before
+		// whatever tag comes after it instead.
+		if !injected && sawHTML && name != "" && name != "head" {
+			if err := i.writeScripts(w); err != nil {
+				return err
+			}
+			injected = true
+		}
+
+		if _, err := w.Write(z.Raw()); err != nil {
+			return fmt.Errorf("failed to write HTML token: %w", err)
+		}
+
+		if injected || (tt != html.StartTagToken && tt != html.SelfClosingTagToken) {
+			continue
+		}
+
+		switch name {
+		case "head":
+			if err := i.writeScripts(w); err != nil {
+				return err
+			}
+			injected = true
+		case "html":
+			sawHTML = true
+		}
+	}
+}
+
+// writeScripts renders the configured scripts, in orderedScripts order, as
+// raw <script> tags.
+func (i *HTMLInjector) writeScripts(w io.Writer) error {
+	for _, script := range i.orderedScripts() {
+		if _, err := io.WriteString(w, "<script"); err != nil {
+			return err
+		}
+		for key, value := range script.Attributes {
+			if _, err := fmt.Fprintf(w, ` %s="%s"`, key, html.EscapeString(value)); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, ">"); err != nil {
+			return err
+		}
+		if script.Content != "" {
+			if _, err := io.WriteString(w, strings.TrimSpace(script.Content)); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, "</script>"); err != nil {
+			return err
+		}
+	}
+	return nil
+}